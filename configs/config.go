@@ -19,14 +19,77 @@ type Config struct {
 			Enable           bool     `mapstructure:"ENABLE"`
 			MaxAgeSeconds    int      `mapstructure:"MAX_AGE_SECONDS"`
 		}
-		Name     string `mapstructure:"NAME"`
-		Revision string `mapstructure:"REVISION"`
-		URL      string `mapstructure:"URL"`
-		JWTSecret string  `mapstructure:"JWT_SECRET"`
+		Name      string `mapstructure:"NAME"`
+		Revision  string `mapstructure:"REVISION"`
+		URL       string `mapstructure:"URL"`
+		JWTSecret string `mapstructure:"JWT_SECRET"`
+	}
+
+	Auth struct {
+		Authenticators []string `mapstructure:"AUTHENTICATORS"`
+		JWT            struct {
+			AccessTokenExpirySeconds  int64             `mapstructure:"ACCESS_TOKEN_EXPIRY_SECONDS"`
+			AcceptLegacySecret        bool              `mapstructure:"ACCEPT_LEGACY_SECRET"`
+			RefreshTokenExpirySeconds int64             `mapstructure:"REFRESH_TOKEN_EXPIRY_SECONDS"`
+			Keys                      map[string]string `mapstructure:"KEYS"`
+			SigningKeyID              string            `mapstructure:"SIGNING_KEY_ID"`
+		}
+		RBAC struct {
+			RolePermissions map[string][]string `mapstructure:"ROLE_PERMISSIONS"`
+		}
+		PasswordReset struct {
+			TokenExpirySeconds int64 `mapstructure:"TOKEN_EXPIRY_SECONDS"`
+		} `mapstructure:"PASSWORD_RESET"`
+		Password struct {
+			Algorithm           string `mapstructure:"ALGORITHM"`
+			BcryptCost          int    `mapstructure:"BCRYPT_COST"`
+			MinLength           int    `mapstructure:"MIN_LENGTH"`
+			MaxLength           int    `mapstructure:"MAX_LENGTH"`
+			RequireUppercase    bool   `mapstructure:"REQUIRE_UPPERCASE"`
+			RequireLowercase    bool   `mapstructure:"REQUIRE_LOWERCASE"`
+			RequireDigit        bool   `mapstructure:"REQUIRE_DIGIT"`
+			RequireSymbol       bool   `mapstructure:"REQUIRE_SYMBOL"`
+			CommonPasswordsFile string `mapstructure:"COMMON_PASSWORDS_FILE"`
+			Argon2id            struct {
+				Memory      uint32 `mapstructure:"MEMORY"`
+				Iterations  uint32 `mapstructure:"ITERATIONS"`
+				Parallelism uint8  `mapstructure:"PARALLELISM"`
+				SaltLength  uint32 `mapstructure:"SALT_LENGTH"`
+				KeyLength   uint32 `mapstructure:"KEY_LENGTH"`
+			} `mapstructure:"ARGON2ID"`
+		}
+		Verification struct {
+			Required           bool  `mapstructure:"REQUIRED"`
+			TokenExpirySeconds int64 `mapstructure:"TOKEN_EXPIRY_SECONDS"`
+		}
+		TwoFactor struct {
+			Issuer                 string `mapstructure:"ISSUER"`
+			EncryptionKey          string `mapstructure:"ENCRYPTION_KEY"`
+			ChallengeExpirySeconds int64  `mapstructure:"CHALLENGE_EXPIRY_SECONDS"`
+		} `mapstructure:"TWO_FACTOR"`
+		Lockout struct {
+			MaxAttempts        int64 `mapstructure:"MAX_ATTEMPTS"`
+			IPMaxAttempts      int64 `mapstructure:"IP_MAX_ATTEMPTS"`
+			WindowSeconds      int64 `mapstructure:"WINDOW_SECONDS"`
+			BaseLockoutSeconds int64 `mapstructure:"BASE_LOCKOUT_SECONDS"`
+			MaxLockoutSeconds  int64 `mapstructure:"MAX_LOCKOUT_SECONDS"`
+		}
+		OAuth struct {
+			AccessTokenExpirySeconds       int64    `mapstructure:"ACCESS_TOKEN_EXPIRY_SECONDS"`
+			AuthorizationCodeExpirySeconds int64    `mapstructure:"AUTHORIZATION_CODE_EXPIRY_SECONDS"`
+			RefreshTokenExpirySeconds      int64    `mapstructure:"REFRESH_TOKEN_EXPIRY_SECONDS"`
+			SecretRotationOverlapSeconds   int64    `mapstructure:"SECRET_ROTATION_OVERLAP_SECONDS"`
+			TokenCacheSize                 int      `mapstructure:"TOKEN_CACHE_SIZE"`
+			TokenCacheTTLSeconds           int64    `mapstructure:"TOKEN_CACHE_TTL_SECONDS"`
+			TokenCleanupIntervalSeconds    int64    `mapstructure:"TOKEN_CLEANUP_INTERVAL_SECONDS"`
+			TokenCleanupBatchSize          int      `mapstructure:"TOKEN_CLEANUP_BATCH_SIZE"`
+			ClientScope                    []string `mapstructure:"CLIENT_SCOPE"`
+		} `mapstructure:"OAUTH"`
 	}
 
 	Cache struct {
 		Redis struct {
+			Enabled bool `mapstructure:"ENABLED"`
 			Primary struct {
 				Host     string `mapstructure:"HOST"`
 				Port     string `mapstructure:"PORT"`
@@ -93,6 +156,13 @@ type Config struct {
 		}
 	}
 
+	Notifier struct {
+		Driver string `mapstructure:"DRIVER"`
+		File   struct {
+			Path string `mapstructure:"PATH"`
+		}
+	}
+
 	Server struct {
 		Env      string `mapstructure:"ENV"`
 		LogLevel string `mapstructure:"LOG_LEVEL"`
@@ -22,3 +22,14 @@ func RedisNewClient(config configs.Config) *redis.Client {
 
 	return client
 }
+
+// ProvideRedisClient is the provider for the primary Redis client. It returns
+// nil when Redis is disabled so that dependants can fall back to in-memory
+// implementations.
+func ProvideRedisClient(config *configs.Config) *redis.Client {
+	if !config.Cache.Redis.Enabled {
+		return nil
+	}
+
+	return RedisNewClient(*config)
+}
@@ -0,0 +1,181 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// keyPrefixLength is the number of random bytes of a key's prefix, which
+// identifies the key and is safe to show.
+const keyPrefixLength = 6
+
+// APIKey is a key services present in the X-API-Key header instead of going
+// through OAuth. A key is "<prefix>.<secret>"; only the prefix and the hash
+// of the whole key are stored. Scope is a space-delimited list.
+type APIKey struct {
+	ID         uuid.UUID   `db:"id"`
+	Name       string      `db:"name"`
+	Prefix     string      `db:"prefix"`
+	KeyHash    string      `db:"key_hash"`
+	Scope      string      `db:"scope"`
+	ExpiresAt  null.Time   `db:"expires_at"`
+	LastUsedAt null.Time   `db:"last_used_at"`
+	CreatedAt  time.Time   `db:"created_at"`
+	CreatedBy  uuid.UUID   `db:"created_by"`
+	RevokedAt  null.Time   `db:"revoked_at"`
+	RevokedBy  nuuid.NUUID `db:"revoked_by"`
+	// Key is the plaintext key, only known right after it is generated.
+	Key string `db:"-"`
+}
+
+// NewFromRequestFormat creates a new APIKey with a generated key.
+func (k APIKey) NewFromRequestFormat(req APIKeyRequestFormat, userID uuid.UUID) (newKey APIKey, err error) {
+	for _, scope := range req.Scopes {
+		if strings.ContainsAny(scope, " \t") {
+			return newKey, errors.New("scopes must not contain whitespace")
+		}
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	newKey = APIKey{
+		ID:        id,
+		Name:      req.Name,
+		Scope:     strings.Join(req.Scopes, " "),
+		CreatedAt: now,
+		CreatedBy: userID,
+	}
+
+	if req.ExpiresInSeconds > 0 {
+		newKey.ExpiresAt = null.TimeFrom(now.Add(time.Duration(req.ExpiresInSeconds) * time.Second))
+	}
+
+	err = newKey.generateKey()
+	return
+}
+
+// IsExpired checks whether an APIKey has expired.
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt.Valid && !time.Now().Before(k.ExpiresAt.Time)
+}
+
+// IsRevoked checks whether an APIKey has been revoked.
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt.Valid
+}
+
+// Revoke marks an APIKey as revoked by a user.
+func (k *APIKey) Revoke(userID uuid.UUID) (err error) {
+	if k.IsRevoked() {
+		return errors.New("API key is already revoked")
+	}
+
+	k.RevokedAt = null.TimeFrom(time.Now())
+	k.RevokedBy = nuuid.From(userID)
+	return
+}
+
+// Scopes returns the scopes granted to an APIKey.
+func (k *APIKey) Scopes() []string {
+	return oauth.ParseScope(k.Scope)
+}
+
+// VerifyKey checks in constant time whether key is this APIKey.
+func (k *APIKey) VerifyKey(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashKey(key)), []byte(k.KeyHash)) == 1
+}
+
+func (k *APIKey) generateKey() (err error) {
+	prefix := make([]byte, keyPrefixLength)
+	if _, err = rand.Read(prefix); err != nil {
+		return
+	}
+
+	secret, err := oauth.GenerateClientSecret()
+	if err != nil {
+		return
+	}
+
+	k.Prefix = base64.RawURLEncoding.EncodeToString(prefix)
+	k.Key = k.Prefix + "." + secret
+	k.KeyHash = HashKey(k.Key)
+	return
+}
+
+// HashKey hashes an API key for storage.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseKeyPrefix returns the prefix of an API key.
+func ParseKeyPrefix(key string) (prefix string, ok bool) {
+	i := strings.Index(key, ".")
+	if i <= 0 || i == len(key)-1 {
+		return "", false
+	}
+
+	return key[:i], true
+}
+
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.ToResponseFormat())
+}
+
+func (k APIKey) ToResponseFormat() APIKeyResponseFormat {
+	return APIKeyResponseFormat{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Key:        k.Key,
+		Scopes:     k.Scopes(),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+		CreatedBy:  k.CreatedBy,
+		Revoked:    k.IsRevoked(),
+		RevokedAt:  k.RevokedAt,
+		RevokedBy:  k.RevokedBy.Ptr(),
+	}
+}
+
+// APIKeyRequestFormat describes a key to be issued. Leaving ExpiresInSeconds
+// out issues a key that does not expire.
+type APIKeyRequestFormat struct {
+	Name             string   `json:"name" validate:"required,max=100"`
+	Scopes           []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresInSeconds int64    `json:"expiresInSeconds" validate:"omitempty,min=1"`
+}
+
+// APIKeyResponseFormat describes an APIKey. Key is only filled in right after
+// the key is issued.
+type APIKeyResponseFormat struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  null.Time  `json:"expiresAt"`
+	LastUsedAt null.Time  `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	CreatedBy  uuid.UUID  `json:"createdBy"`
+	Revoked    bool       `json:"revoked"`
+	RevokedAt  null.Time  `json:"revokedAt"`
+	RevokedBy  *uuid.UUID `json:"revokedBy,omitempty"`
+}
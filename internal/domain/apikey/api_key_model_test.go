@@ -0,0 +1,59 @@
+package apikey_test
+
+import (
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/apikey"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	request := apikey.APIKeyRequestFormat{Name: "batch", Scopes: []string{"foo:read", "foo:write"}}
+
+	t.Run("stores only the hash and prefix of a generated key", func(t *testing.T) {
+		key, err := apikey.APIKey{}.NewFromRequestFormat(request, userID)
+		assert.NoError(t, err)
+		assert.NotContains(t, key.KeyHash, key.Key)
+
+		prefix, ok := apikey.ParseKeyPrefix(key.Key)
+		assert.True(t, ok)
+		assert.Equal(t, key.Prefix, prefix)
+		assert.True(t, key.VerifyKey(key.Key))
+		assert.False(t, key.VerifyKey(key.Prefix+".wrong"))
+		assert.Equal(t, []string{"foo:read", "foo:write"}, key.Scopes())
+		assert.False(t, key.ExpiresAt.Valid)
+	})
+
+	t.Run("rejects scopes with whitespace", func(t *testing.T) {
+		_, err := apikey.APIKey{}.NewFromRequestFormat(apikey.APIKeyRequestFormat{Name: "batch", Scopes: []string{"foo read"}}, userID)
+		assert.Error(t, err)
+	})
+
+	t.Run("expires after the requested time", func(t *testing.T) {
+		expiring := request
+		expiring.ExpiresInSeconds = 60
+		key, err := apikey.APIKey{}.NewFromRequestFormat(expiring, userID)
+		assert.NoError(t, err)
+		assert.True(t, key.ExpiresAt.Valid)
+		assert.False(t, key.IsExpired())
+
+		key.ExpiresAt.Time = key.CreatedAt.Add(-1)
+		assert.True(t, key.IsExpired())
+	})
+
+	t.Run("can only be revoked once", func(t *testing.T) {
+		key, _ := apikey.APIKey{}.NewFromRequestFormat(request, userID)
+		assert.NoError(t, key.Revoke(userID))
+		assert.True(t, key.IsRevoked())
+		assert.Error(t, key.Revoke(userID))
+	})
+
+	t.Run("parses malformed keys as having no prefix", func(t *testing.T) {
+		for _, key := range []string{"", "nodot", ".secret", "prefix."} {
+			_, ok := apikey.ParseKeyPrefix(key)
+			assert.False(t, ok, key)
+		}
+	})
+}
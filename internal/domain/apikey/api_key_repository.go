@@ -0,0 +1,185 @@
+package apikey
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var apiKeyQueries = struct {
+	selectAPIKey     string
+	insertAPIKey     string
+	revokeAPIKey     string
+	updateLastUsedAt string
+}{
+	selectAPIKey: `
+		SELECT
+			id,
+			name,
+			prefix,
+			key_hash,
+			scope,
+			expires_at,
+			last_used_at,
+			created_at,
+			created_by,
+			revoked_at,
+			revoked_by
+		FROM api_keys`,
+
+	insertAPIKey: `
+		INSERT INTO api_keys (
+			id,
+			name,
+			prefix,
+			key_hash,
+			scope,
+			expires_at,
+			last_used_at,
+			created_at,
+			created_by,
+			revoked_at,
+			revoked_by
+		) VALUES (
+			:id,
+			:name,
+			:prefix,
+			:key_hash,
+			:scope,
+			:expires_at,
+			:last_used_at,
+			:created_at,
+			:created_by,
+			:revoked_at,
+			:revoked_by)`,
+
+	revokeAPIKey: `
+		UPDATE api_keys
+		SET
+			revoked_at = :revoked_at,
+			revoked_by = :revoked_by
+		WHERE id = :id AND revoked_at IS NULL`,
+
+	updateLastUsedAt: `
+		UPDATE api_keys
+		SET last_used_at = ?
+		WHERE id = ?`,
+}
+
+// APIKeyRepository is the repository for APIKey data.
+type APIKeyRepository interface {
+	Create(apiKey APIKey) (err error)
+	ResolveAll() (apiKeys []APIKey, err error)
+	ResolveByID(id uuid.UUID) (apiKey APIKey, err error)
+	ResolveByPrefix(prefix string) (apiKey APIKey, err error)
+	Revoke(apiKey APIKey) (err error)
+	UpdateLastUsedAt(id uuid.UUID, lastUsedAt time.Time) (err error)
+}
+
+// APIKeyRepositoryMySQL is the MySQL-backed implementation of APIKeyRepository.
+type APIKeyRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideAPIKeyRepositoryMySQL is the provider for this repository.
+func ProvideAPIKeyRepositoryMySQL(db *infras.MySQLConn) *APIKeyRepositoryMySQL {
+	s := new(APIKeyRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// Create creates a new APIKey.
+func (r *APIKeyRepositoryMySQL) Create(apiKey APIKey) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		stmt, err := tx.PrepareNamed(apiKeyQueries.insertAPIKey)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(apiKey); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// ResolveAll resolves every APIKey, most recently created first.
+func (r *APIKeyRepositoryMySQL) ResolveAll() (apiKeys []APIKey, err error) {
+	err = r.DB.Read.Select(&apiKeys, apiKeyQueries.selectAPIKey+" ORDER BY created_at DESC")
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveByID resolves an APIKey by its ID.
+func (r *APIKeyRepositoryMySQL) ResolveByID(id uuid.UUID) (apiKey APIKey, err error) {
+	return r.resolveOne(apiKeyQueries.selectAPIKey+" WHERE id = ?", id.String())
+}
+
+// ResolveByPrefix resolves an APIKey by the prefix of its key.
+func (r *APIKeyRepositoryMySQL) ResolveByPrefix(prefix string) (apiKey APIKey, err error) {
+	return r.resolveOne(apiKeyQueries.selectAPIKey+" WHERE prefix = ?", prefix)
+}
+
+// Revoke stores the revocation of an APIKey. It fails with a conflict if the
+// APIKey is already revoked.
+func (r *APIKeyRepositoryMySQL) Revoke(apiKey APIKey) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		result, err := tx.NamedExec(apiKeyQueries.revokeAPIKey, apiKey)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			e <- err
+			return
+		}
+
+		if affected == 0 {
+			e <- failure.Conflict("revoke", "API key", "already revoked")
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// UpdateLastUsedAt records when an APIKey was last used.
+func (r *APIKeyRepositoryMySQL) UpdateLastUsedAt(id uuid.UUID, lastUsedAt time.Time) (err error) {
+	_, err = r.DB.Write.Exec(apiKeyQueries.updateLastUsedAt, lastUsedAt, id.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+func (r *APIKeyRepositoryMySQL) resolveOne(query string, arg interface{}) (apiKey APIKey, err error) {
+	err = r.DB.Read.Get(&apiKey, query, arg)
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("API key")
+		return
+	}
+
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
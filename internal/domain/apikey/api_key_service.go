@@ -0,0 +1,122 @@
+package apikey
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+)
+
+// lastUsedResolution bounds how often using a key writes its last-used time,
+// so busy keys do not cost a write on every request.
+const lastUsedResolution = time.Minute
+
+// APIKeyService is the service interface for APIKey entities.
+type APIKeyService interface {
+	Authenticate(key string) (apiKey APIKey, err error)
+	Create(requestFormat APIKeyRequestFormat, userID uuid.UUID) (apiKey APIKey, err error)
+	ResolveAll() (apiKeys []APIKey, err error)
+	ResolveByID(id uuid.UUID) (apiKey APIKey, err error)
+	Revoke(id uuid.UUID, userID uuid.UUID) (apiKey APIKey, err error)
+}
+
+// APIKeyServiceImpl is the service implementation for APIKey entities.
+type APIKeyServiceImpl struct {
+	APIKeyRepository APIKeyRepository
+}
+
+// ProvideAPIKeyServiceImpl is the provider for this service.
+func ProvideAPIKeyServiceImpl(apiKeyRepository APIKeyRepository) *APIKeyServiceImpl {
+	s := new(APIKeyServiceImpl)
+	s.APIKeyRepository = apiKeyRepository
+
+	return s
+}
+
+// Authenticate resolves the APIKey of a key presented by a caller and records
+// that it was used. Unknown, revoked and expired keys are all rejected with
+// the same unauthorized failure.
+func (s *APIKeyServiceImpl) Authenticate(key string) (apiKey APIKey, err error) {
+	prefix, ok := ParseKeyPrefix(key)
+	if !ok {
+		return apiKey, failure.Unauthorized("invalid API key")
+	}
+
+	apiKey, err = s.APIKeyRepository.ResolveByPrefix(prefix)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.Unauthorized("invalid API key")
+		}
+		return
+	}
+
+	if !apiKey.VerifyKey(key) || apiKey.IsRevoked() || apiKey.IsExpired() {
+		return APIKey{}, failure.Unauthorized("invalid API key")
+	}
+
+	now := time.Now()
+	if !apiKey.LastUsedAt.Valid || now.Sub(apiKey.LastUsedAt.Time) >= lastUsedResolution {
+		// a failed write only costs the last-used time, not the request
+		if s.APIKeyRepository.UpdateLastUsedAt(apiKey.ID, now) == nil {
+			apiKey.LastUsedAt.SetValid(now)
+		}
+	}
+
+	return
+}
+
+// AuthenticateAPIKey authenticates a key like Authenticate and returns the
+// key's ID, the user who created it and its scopes.
+func (s *APIKeyServiceImpl) AuthenticateAPIKey(key string) (id uuid.UUID, createdBy uuid.UUID, scopes []string, err error) {
+	apiKey, err := s.Authenticate(key)
+	if err != nil {
+		return
+	}
+
+	return apiKey.ID, apiKey.CreatedBy, apiKey.Scopes(), nil
+}
+
+// Create issues a new APIKey. The returned APIKey carries its plaintext key,
+// which is not stored and cannot be shown again.
+func (s *APIKeyServiceImpl) Create(requestFormat APIKeyRequestFormat, userID uuid.UUID) (apiKey APIKey, err error) {
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return apiKey, failure.BadRequest(err)
+	}
+
+	apiKey, err = apiKey.NewFromRequestFormat(requestFormat, userID)
+	if err != nil {
+		return apiKey, failure.BadRequest(err)
+	}
+
+	err = s.APIKeyRepository.Create(apiKey)
+	return
+}
+
+// ResolveAll resolves every APIKey.
+func (s *APIKeyServiceImpl) ResolveAll() (apiKeys []APIKey, err error) {
+	return s.APIKeyRepository.ResolveAll()
+}
+
+// ResolveByID resolves an APIKey by its ID.
+func (s *APIKeyServiceImpl) ResolveByID(id uuid.UUID) (apiKey APIKey, err error) {
+	return s.APIKeyRepository.ResolveByID(id)
+}
+
+// Revoke revokes an APIKey, which is rejected from then on.
+func (s *APIKeyServiceImpl) Revoke(id uuid.UUID, userID uuid.UUID) (apiKey APIKey, err error) {
+	apiKey, err = s.APIKeyRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	err = apiKey.Revoke(userID)
+	if err != nil {
+		return apiKey, failure.Conflict("revoke", "API key", err.Error())
+	}
+
+	err = s.APIKeyRepository.Revoke(apiKey)
+	return
+}
@@ -1,8 +1,11 @@
 package foobarbaz
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/evermos/boilerplate-go/shared"
@@ -267,6 +270,143 @@ type FooResponseFormat struct {
 	Items         []FooItemResponseFormat `json:"items"`
 }
 
+//// Foo Listing
+
+const (
+	defaultFooPageSize = 20
+	maxFooPageSize     = 100
+)
+
+// FooSort is the order Foos are listed in. A leading "-" sorts descending.
+type FooSort string
+
+const (
+	// FooSortCreatedAsc lists the oldest Foos first.
+	FooSortCreatedAsc FooSort = "created"
+	// FooSortCreatedDesc lists the newest Foos first.
+	FooSortCreatedDesc FooSort = "-created"
+	// FooSortNameAsc lists Foos by name.
+	FooSortNameAsc FooSort = "name"
+	// FooSortNameDesc lists Foos by name, in reverse.
+	FooSortNameDesc FooSort = "-name"
+)
+
+// column returns the column a FooSort orders by.
+func (s FooSort) column() string {
+	return "foo." + strings.TrimPrefix(string(s), "-")
+}
+
+// descending checks whether a FooSort orders descending.
+func (s FooSort) descending() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// FooFilter holds the criteria for listing Foos. Cursor is the NextCursor of
+// the previous page and must be used with the same Sort.
+type FooFilter struct {
+	Status      FooStatus
+	CreatedFrom null.Time
+	CreatedTo   null.Time
+	CreatedBy   nuuid.NUUID
+	Name        string
+	Sort        FooSort
+	Cursor      string
+	PageSize    int
+	WithItems   bool
+
+	after *fooCursor
+}
+
+// Normalize applies the default sort and page size to this filter, and
+// decodes and checks its cursor.
+func (f *FooFilter) Normalize() (err error) {
+	switch f.Status {
+	case "", FooStatusNew, FooStatusPending, FooStatusVerified, FooStatusPaid,
+		FooStatusInTransit, FooStatusDelivered, FooStatusFailedToDeliver:
+	default:
+		return fmt.Errorf("unknown status %q", f.Status)
+	}
+
+	if f.Sort == "" {
+		f.Sort = FooSortCreatedDesc
+	}
+
+	switch f.Sort {
+	case FooSortCreatedAsc, FooSortCreatedDesc, FooSortNameAsc, FooSortNameDesc:
+	default:
+		return fmt.Errorf("unknown sort %q", f.Sort)
+	}
+
+	if f.PageSize < 1 {
+		f.PageSize = defaultFooPageSize
+	}
+
+	if f.PageSize > maxFooPageSize {
+		f.PageSize = maxFooPageSize
+	}
+
+	f.after = nil
+	if f.Cursor != "" {
+		cursor, err := decodeFooCursor(f.Cursor)
+		if err == nil && cursor.Sort == f.Sort {
+			_, err = cursor.value()
+		}
+		if err != nil || cursor.Sort != f.Sort {
+			return errors.New("invalid cursor")
+		}
+		f.after = &cursor
+	}
+
+	return
+}
+
+// fooCursor points at the last Foo of a page: the value of its sort column
+// and its ID, which breaks ties between equal values.
+type fooCursor struct {
+	Sort  FooSort   `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func newFooCursor(sort FooSort, foo Foo) fooCursor {
+	cursor := fooCursor{Sort: sort, ID: foo.ID, Value: foo.Name}
+	if sort == FooSortCreatedAsc || sort == FooSortCreatedDesc {
+		cursor.Value = foo.Created.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+func decodeFooCursor(encoded string) (cursor fooCursor, err error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(decoded, &cursor)
+	return
+}
+
+// encode returns the opaque form of a cursor handed to clients.
+func (c fooCursor) encode() string {
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// value returns the sort column value of a cursor as a query argument.
+func (c fooCursor) value() (value interface{}, err error) {
+	if c.Sort == FooSortCreatedAsc || c.Sort == FooSortCreatedDesc {
+		return time.Parse(time.RFC3339Nano, c.Value)
+	}
+	return c.Value, nil
+}
+
+// FooPage is a single page of Foos. NextCursor is empty on the last page.
+type FooPage struct {
+	Foos       []Foo
+	NextCursor string
+	PageSize   int
+}
+
 //// Foo Item
 
 // FooItem is a sample child entity model.
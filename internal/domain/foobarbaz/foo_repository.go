@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
 	"github.com/gofrs/uuid"
@@ -134,6 +135,7 @@ var (
 type FooRepository interface {
 	Create(foo Foo) (err error)
 	ExistsByID(id uuid.UUID) (exists bool, err error)
+	ResolveAll(filter FooFilter, limit int) (foos []Foo, err error)
 	ResolveByID(id uuid.UUID) (foo Foo, err error)
 	ResolveItemsByFooIDs(ids []uuid.UUID) (fooItems []FooItem, err error)
 	Update(foo Foo) (err error)
@@ -193,6 +195,65 @@ func (r *FooRepositoryMySQL) ExistsByID(id uuid.UUID) (exists bool, err error) {
 	return
 }
 
+// ResolveAll resolves at most limit Foos that are not deleted, match filter
+// and come after its cursor, in the order of its sort.
+func (r *FooRepositoryMySQL) ResolveAll(filter FooFilter, limit int) (foos []Foo, err error) {
+	conditions := []string{"foo.deleted IS NULL"}
+	args := []interface{}{}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "foo.status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.CreatedFrom.Valid {
+		conditions = append(conditions, "foo.created >= ?")
+		args = append(args, filter.CreatedFrom.Time)
+	}
+
+	if filter.CreatedTo.Valid {
+		conditions = append(conditions, "foo.created < ?")
+		args = append(args, filter.CreatedTo.Time)
+	}
+
+	if filter.CreatedBy.Valid {
+		conditions = append(conditions, "foo.created_by = ?")
+		args = append(args, filter.CreatedBy.UUID.String())
+	}
+
+	if filter.Name != "" {
+		conditions = append(conditions, "foo.name LIKE ?")
+		args = append(args, "%"+shared.EscapeLike(filter.Name)+"%")
+	}
+
+	column := filter.Sort.column()
+	operator, direction := ">", "ASC"
+	if filter.Sort.descending() {
+		operator, direction = "<", "DESC"
+	}
+
+	if filter.after != nil {
+		value, err := filter.after.value()
+		if err != nil {
+			return foos, failure.BadRequest(err)
+		}
+
+		conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND foo.entity_id %[2]s ?))", column, operator))
+		args = append(args, value, value, filter.after.ID.String())
+	}
+
+	query := fmt.Sprintf("%s WHERE %s ORDER BY %s %s, foo.entity_id %s LIMIT ?",
+		fooQueries.selectFoo, strings.Join(conditions, " AND "), column, direction, direction)
+
+	foos = make([]Foo, 0)
+	err = r.DB.Read.Select(&foos, query, append(args, limit)...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
 // ResolveByID resolves a Foo by its ID
 func (r *FooRepositoryMySQL) ResolveByID(id uuid.UUID) (foo Foo, err error) {
 	err = r.DB.Read.Get(
@@ -13,6 +13,7 @@ import (
 // FooService is the service interface for Foo entities.
 type FooService interface {
 	Create(requestFormat FooRequestFormat, userID uuid.UUID) (foo Foo, err error)
+	ResolveAll(filter FooFilter) (page FooPage, err error)
 	ResolveByID(id uuid.UUID, withItems bool) (foo Foo, err error)
 	SoftDelete(id uuid.UUID, userID uuid.UUID) (foo Foo, err error)
 	Update(id uuid.UUID, requestFormat FooRequestFormat, userID uuid.UUID) (foo Foo, err error)
@@ -63,6 +64,45 @@ func (s *FooServiceImpl) Create(requestFormat FooRequestFormat, userID uuid.UUID
 	return
 }
 
+// ResolveAll resolves a page of Foos matching filter. When requested, the
+// items of the whole page are resolved at once.
+func (s *FooServiceImpl) ResolveAll(filter FooFilter) (page FooPage, err error) {
+	err = filter.Normalize()
+	if err != nil {
+		return page, failure.BadRequest(err)
+	}
+
+	// one extra Foo tells whether there is a next page
+	foos, err := s.FooRepository.ResolveAll(filter, filter.PageSize+1)
+	if err != nil {
+		return
+	}
+
+	page = FooPage{Foos: foos, PageSize: filter.PageSize}
+	if len(foos) > filter.PageSize {
+		page.Foos = foos[:filter.PageSize]
+		page.NextCursor = newFooCursor(filter.Sort, page.Foos[filter.PageSize-1]).encode()
+	}
+
+	if filter.WithItems && len(page.Foos) > 0 {
+		ids := make([]uuid.UUID, 0, len(page.Foos))
+		for _, foo := range page.Foos {
+			ids = append(ids, foo.ID)
+		}
+
+		items, err := s.FooRepository.ResolveItemsByFooIDs(ids)
+		if err != nil {
+			return page, err
+		}
+
+		for i := range page.Foos {
+			page.Foos[i].AttachItems(items)
+		}
+	}
+
+	return
+}
+
 // ResolveByID resolves a Foo by its ID.
 func (s *FooServiceImpl) ResolveByID(id uuid.UUID, withItems bool) (foo Foo, err error) {
 	foo, err = s.FooRepository.ResolveByID(id)
@@ -1,11 +1,13 @@
 package foobarbaz_test
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
 	foobarbaz_mock "github.com/evermos/boilerplate-go/internal/domain/foobarbaz/mock"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/golang/mock/gomock"
@@ -101,4 +103,58 @@ func TestFooService(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("resolveAll", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		foos := []foobarbaz.Foo{
+			{ID: getRandomUUID(), Name: "Foo 3", Created: time.Now()},
+			{ID: getRandomUUID(), Name: "Foo 2", Created: time.Now().Add(-time.Minute)},
+			{ID: getRandomUUID(), Name: "Foo 1", Created: time.Now().Add(-2 * time.Minute)},
+		}
+		items := []foobarbaz.FooItem{
+			{ID: getRandomUUID(), FooID: foos[0].ID},
+			{ID: getRandomUUID(), FooID: foos[1].ID},
+		}
+
+		mockRepo := foobarbaz_mock.NewMockFooRepository(ctrl)
+		s := &foobarbaz.FooServiceImpl{
+			FooRepository: mockRepo,
+		}
+
+		t.Run("pages with a single batched items lookup", func(t *testing.T) {
+			mockRepo.EXPECT().ResolveAll(gomock.Any(), 3).Return(foos, nil)
+			mockRepo.EXPECT().ResolveItemsByFooIDs([]uuid.UUID{foos[0].ID, foos[1].ID}).Return(items, nil)
+
+			page, err := s.ResolveAll(foobarbaz.FooFilter{PageSize: 2, WithItems: true})
+			assert.NoError(t, err)
+			assert.Len(t, page.Foos, 2)
+			assert.NotEmpty(t, page.NextCursor)
+			assert.Len(t, page.Foos[0].Items, 1)
+			assert.Len(t, page.Foos[1].Items, 1)
+
+			mockRepo.EXPECT().ResolveAll(gomock.Any(), 3).Return(foos[2:], nil)
+
+			page, err = s.ResolveAll(foobarbaz.FooFilter{PageSize: 2, Cursor: page.NextCursor})
+			assert.NoError(t, err)
+			assert.Len(t, page.Foos, 1)
+			assert.Empty(t, page.NextCursor)
+		})
+
+		t.Run("rejects invalid filters", func(t *testing.T) {
+			mockRepo.EXPECT().ResolveAll(gomock.Any(), 3).Return(foos, nil)
+			page, _ := s.ResolveAll(foobarbaz.FooFilter{PageSize: 2})
+
+			for _, filter := range []foobarbaz.FooFilter{
+				{Sort: "price"},
+				{Status: "lost"},
+				{Cursor: "not a cursor"},
+				{Cursor: page.NextCursor, Sort: foobarbaz.FooSortNameAsc},
+			} {
+				_, err := s.ResolveAll(filter)
+				assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+			}
+		})
+	})
 }
@@ -0,0 +1,146 @@
+package oauthclient
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/guregu/null"
+)
+
+// Client is an OAuth client allowed to request tokens. ClientSecret holds the
+// hash of its secret; while a rotation overlap lasts, PreviousClientSecret
+// holds the hash of the secret it replaced. RedirectURI, GrantTypes and Scope
+// are space-delimited lists.
+type Client struct {
+	ClientID              string      `db:"client_id"`
+	ClientSecret          string      `db:"client_secret"`
+	PreviousClientSecret  null.String `db:"previous_client_secret"`
+	PreviousSecretExpires null.Time   `db:"previous_secret_expires"`
+	RedirectURI           null.String `db:"redirect_uri"`
+	GrantTypes            string      `db:"grant_types"`
+	Scope                 null.String `db:"scope"`
+	DisabledAt            null.Time   `db:"disabled_at"`
+	CreatedAt             time.Time   `db:"created_at"`
+	UpdatedAt             null.Time   `db:"updated_at"`
+	// Secret is the plaintext secret, only known right after it is generated.
+	Secret string `db:"-"`
+}
+
+// NewFromRequestFormat creates a new Client with a generated secret.
+func (c Client) NewFromRequestFormat(req ClientRequestFormat) (newClient Client, err error) {
+	for _, scope := range req.Scopes {
+		if strings.ContainsAny(scope, " \t") {
+			return newClient, errors.New("scopes must not contain whitespace")
+		}
+	}
+
+	for _, grantType := range req.GrantTypes {
+		if grantType == string(oauth.AuthorizationCode) && len(req.RedirectURIs) == 0 {
+			return newClient, errors.New("the authorization_code grant requires at least one redirect URI")
+		}
+	}
+
+	newClient = Client{
+		ClientID:   req.ClientID,
+		GrantTypes: strings.Join(req.GrantTypes, " "),
+		CreatedAt:  time.Now(),
+	}
+
+	if len(req.RedirectURIs) > 0 {
+		newClient.RedirectURI = null.StringFrom(strings.Join(req.RedirectURIs, " "))
+	}
+
+	if len(req.Scopes) > 0 {
+		newClient.Scope = null.StringFrom(strings.Join(req.Scopes, " "))
+	}
+
+	err = newClient.generateSecret()
+	return
+}
+
+// IsDisabled checks whether a Client is disabled.
+func (c *Client) IsDisabled() bool {
+	return c.DisabledAt.Valid
+}
+
+// RotateSecret generates a new secret for a Client. The current secret keeps
+// working for overlap, so deployed callers can switch without downtime.
+func (c *Client) RotateSecret(overlap time.Duration) (err error) {
+	previous := c.ClientSecret
+	err = c.generateSecret()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	c.PreviousClientSecret = null.NewString(previous, overlap > 0)
+	c.PreviousSecretExpires = null.NewTime(now.Add(overlap), overlap > 0)
+	c.UpdatedAt = null.TimeFrom(now)
+	return
+}
+
+func (c *Client) generateSecret() (err error) {
+	c.Secret, err = oauth.GenerateClientSecret()
+	if err != nil {
+		return
+	}
+
+	c.ClientSecret, err = oauth.HashClientSecret(c.Secret)
+	return
+}
+
+func (c Client) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.ToResponseFormat())
+}
+
+func (c Client) ToResponseFormat() ClientResponseFormat {
+	resp := ClientResponseFormat{
+		ClientID:     c.ClientID,
+		ClientSecret: c.Secret,
+		RedirectURIs: oauth.ParseScope(c.RedirectURI.String),
+		GrantTypes:   oauth.ParseScope(c.GrantTypes),
+		Scopes:       oauth.ParseScope(c.Scope.String),
+		Disabled:     c.IsDisabled(),
+		DisabledAt:   c.DisabledAt,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+
+	if c.PreviousClientSecret.Valid && c.PreviousSecretExpires.Valid && time.Now().Before(c.PreviousSecretExpires.Time) {
+		resp.PreviousSecretExpiresAt = c.PreviousSecretExpires
+	}
+
+	return resp
+}
+
+type ClientRequestFormat struct {
+	ClientID     string   `json:"clientId" validate:"required,max=32,username"`
+	GrantTypes   []string `json:"grantTypes" validate:"required,min=1,dive,oneof=authorization_code client_credentials password refresh_token"`
+	Scopes       []string `json:"scopes" validate:"dive,required"`
+	RedirectURIs []string `json:"redirectUris" validate:"dive,url"`
+}
+
+// RotateSecretRequestFormat sets how long the current secret keeps working
+// after a rotation. Leaving OverlapSeconds out applies the configured
+// default; zero revokes the current secret right away.
+type RotateSecretRequestFormat struct {
+	OverlapSeconds *int64 `json:"overlapSeconds" validate:"omitempty,min=0"`
+}
+
+// ClientResponseFormat describes a Client. ClientSecret is only filled in
+// right after a secret is generated.
+type ClientResponseFormat struct {
+	ClientID                string    `json:"clientId"`
+	ClientSecret            string    `json:"clientSecret,omitempty"`
+	RedirectURIs            []string  `json:"redirectUris"`
+	GrantTypes              []string  `json:"grantTypes"`
+	Scopes                  []string  `json:"scopes"`
+	Disabled                bool      `json:"disabled"`
+	DisabledAt              null.Time `json:"disabledAt"`
+	PreviousSecretExpiresAt null.Time `json:"previousSecretExpiresAt"`
+	CreatedAt               time.Time `json:"createdAt"`
+	UpdatedAt               null.Time `json:"updatedAt"`
+}
@@ -0,0 +1,219 @@
+package oauthclient
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+const mysqlErrDuplicateEntry = 1062
+
+var clientQueries = struct {
+	selectClient        string
+	insertClient        string
+	updateSecret        string
+	disableClient       string
+	deleteAccessTokens  string
+	deleteRefreshTokens string
+}{
+	selectClient: `
+		SELECT
+			client_id,
+			client_secret,
+			previous_client_secret,
+			previous_secret_expires,
+			redirect_uri,
+			grant_types,
+			scope,
+			disabled_at,
+			created_at,
+			updated_at
+		FROM oauth_clients`,
+
+	insertClient: `
+		INSERT INTO oauth_clients (
+			client_id,
+			client_secret,
+			previous_client_secret,
+			previous_secret_expires,
+			redirect_uri,
+			grant_types,
+			scope,
+			disabled_at,
+			created_at,
+			updated_at
+		) VALUES (
+			:client_id,
+			:client_secret,
+			:previous_client_secret,
+			:previous_secret_expires,
+			:redirect_uri,
+			:grant_types,
+			:scope,
+			:disabled_at,
+			:created_at,
+			:updated_at)`,
+
+	updateSecret: `
+		UPDATE oauth_clients
+		SET
+			client_secret = :client_secret,
+			previous_client_secret = :previous_client_secret,
+			previous_secret_expires = :previous_secret_expires,
+			updated_at = :updated_at
+		WHERE client_id = :client_id`,
+
+	disableClient: `
+		UPDATE oauth_clients
+		SET
+			disabled_at = ?,
+			updated_at = ?
+		WHERE client_id = ? AND disabled_at IS NULL`,
+
+	deleteAccessTokens: `
+		DELETE FROM oauth_access_tokens
+		WHERE client_id = ?`,
+
+	deleteRefreshTokens: `
+		DELETE FROM oauth_refresh_tokens
+		WHERE client_id = ?`,
+}
+
+// ClientRepository is the repository for Client data.
+type ClientRepository interface {
+	Create(client Client) (err error)
+	Disable(clientID string) (err error)
+	ResolveAll() (clients []Client, err error)
+	ResolveByClientID(clientID string) (client Client, err error)
+	UpdateSecret(client Client) (err error)
+}
+
+// ClientRepositoryMySQL is the MySQL-backed implementation of ClientRepository.
+type ClientRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideClientRepositoryMySQL is the provider for this repository.
+func ProvideClientRepositoryMySQL(db *infras.MySQLConn) *ClientRepositoryMySQL {
+	s := new(ClientRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// Create creates a new Client.
+func (r *ClientRepositoryMySQL) Create(client Client) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		stmt, err := tx.PrepareNamed(clientQueries.insertClient)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(client); err != nil {
+			if isDuplicateEntry(err) {
+				err = failure.Conflict("create", "client", "client ID is already taken")
+			}
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// Disable disables a Client and deletes every token issued to it. It fails
+// with a conflict if the Client is already disabled.
+func (r *ClientRepositoryMySQL) Disable(clientID string) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		now := time.Now()
+		result, err := tx.Exec(clientQueries.disableClient, now, now, clientID)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			e <- err
+			return
+		}
+
+		if affected == 0 {
+			e <- failure.Conflict("disable", "client", "already disabled")
+			return
+		}
+
+		for _, query := range []string{clientQueries.deleteAccessTokens, clientQueries.deleteRefreshTokens} {
+			if _, err := tx.Exec(query, clientID); err != nil {
+				logger.ErrorWithStack(err)
+				e <- err
+				return
+			}
+		}
+
+		e <- nil
+	})
+}
+
+// ResolveAll resolves every Client, most recently created first.
+func (r *ClientRepositoryMySQL) ResolveAll() (clients []Client, err error) {
+	err = r.DB.Read.Select(&clients, clientQueries.selectClient+" ORDER BY created_at DESC")
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveByClientID resolves a Client by its client ID.
+func (r *ClientRepositoryMySQL) ResolveByClientID(clientID string) (client Client, err error) {
+	err = r.DB.Read.Get(
+		&client,
+		clientQueries.selectClient+" WHERE client_id = ?",
+		clientID)
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("client")
+		return
+	}
+
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// UpdateSecret stores the current and previous secret of a Client.
+func (r *ClientRepositoryMySQL) UpdateSecret(client Client) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		stmt, err := tx.PrepareNamed(clientQueries.updateSecret)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(client); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+func isDuplicateEntry(err error) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == mysqlErrDuplicateEntry
+}
@@ -0,0 +1,121 @@
+package oauthclient
+
+import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+)
+
+const defaultSecretRotationOverlapSeconds = 60 * 60 * 24
+
+// ClientService is the service interface for Client entities.
+type ClientService interface {
+	Create(requestFormat ClientRequestFormat) (client Client, err error)
+	Disable(clientID string) (client Client, err error)
+	ResolveAll() (clients []Client, err error)
+	ResolveByClientID(clientID string) (client Client, err error)
+	RotateSecret(clientID string, requestFormat RotateSecretRequestFormat) (client Client, err error)
+}
+
+// ClientServiceImpl is the service implementation for Client entities.
+type ClientServiceImpl struct {
+	ClientRepository ClientRepository
+	TokenCache       oauth.TokenCache
+	Config           *configs.Config
+}
+
+// ProvideClientServiceImpl is the provider for this service.
+func ProvideClientServiceImpl(clientRepository ClientRepository, tokenCache oauth.TokenCache, config *configs.Config) *ClientServiceImpl {
+	s := new(ClientServiceImpl)
+	s.ClientRepository = clientRepository
+	s.TokenCache = tokenCache
+	s.Config = config
+
+	return s
+}
+
+// Create registers a new Client. The returned Client carries its plaintext
+// secret, which is not stored and cannot be shown again.
+func (s *ClientServiceImpl) Create(requestFormat ClientRequestFormat) (client Client, err error) {
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return client, failure.BadRequest(err)
+	}
+
+	client, err = client.NewFromRequestFormat(requestFormat)
+	if err != nil {
+		return client, failure.BadRequest(err)
+	}
+
+	err = s.ClientRepository.Create(client)
+	return
+}
+
+// Disable disables a Client, revoking every token issued to it.
+func (s *ClientServiceImpl) Disable(clientID string) (client Client, err error) {
+	_, err = s.ClientRepository.ResolveByClientID(clientID)
+	if err != nil {
+		return
+	}
+
+	err = s.ClientRepository.Disable(clientID)
+	if err != nil {
+		return
+	}
+	s.TokenCache.DeleteByClientID(clientID)
+
+	return s.ClientRepository.ResolveByClientID(clientID)
+}
+
+// ResolveAll resolves every Client.
+func (s *ClientServiceImpl) ResolveAll() (clients []Client, err error) {
+	return s.ClientRepository.ResolveAll()
+}
+
+// ResolveByClientID resolves a Client by its client ID.
+func (s *ClientServiceImpl) ResolveByClientID(clientID string) (client Client, err error) {
+	return s.ClientRepository.ResolveByClientID(clientID)
+}
+
+// RotateSecret generates a new secret for a Client. The returned Client
+// carries the new plaintext secret; the old one keeps working for the
+// requested overlap.
+func (s *ClientServiceImpl) RotateSecret(clientID string, requestFormat RotateSecretRequestFormat) (client Client, err error) {
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return client, failure.BadRequest(err)
+	}
+
+	client, err = s.ClientRepository.ResolveByClientID(clientID)
+	if err != nil {
+		return
+	}
+
+	if client.IsDisabled() {
+		return client, failure.Conflict("rotate", "client secret", "client is disabled")
+	}
+
+	overlap := s.secretRotationOverlap()
+	if requestFormat.OverlapSeconds != nil {
+		overlap = time.Duration(*requestFormat.OverlapSeconds) * time.Second
+	}
+
+	err = client.RotateSecret(overlap)
+	if err != nil {
+		return client, failure.InternalError(err)
+	}
+
+	err = s.ClientRepository.UpdateSecret(client)
+	return
+}
+
+func (s *ClientServiceImpl) secretRotationOverlap() time.Duration {
+	seconds := s.Config.Auth.OAuth.SecretRotationOverlapSeconds
+	if seconds <= 0 {
+		seconds = defaultSecretRotationOverlapSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,57 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+const emailVerificationTokenBytes = 32
+
+// EmailVerification is a single-use, time-limited token that proves a user
+// controls the address it signed up with. Only the SHA-256 hash of the token
+// is persisted.
+type EmailVerification struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"userId"`
+	TokenHash string    `db:"tokenHash"`
+	ExpiresAt time.Time `db:"expiresAt"`
+	CreatedAt time.Time `db:"createdAt"`
+	UsedAt    null.Time `db:"usedAt"`
+	Token     string    `db:"-"`
+}
+
+// NewEmailVerification creates a new EmailVerification for a user along with
+// its plaintext token.
+func NewEmailVerification(userID uuid.UUID, ttl time.Duration) (verification EmailVerification, err error) {
+	raw := make([]byte, emailVerificationTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now()
+	verification = EmailVerification{
+		ID:        id,
+		UserID:    userID,
+		TokenHash: HashRefreshToken(plain),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		Token:     plain,
+	}
+
+	return
+}
+
+// IsUsable checks whether this EmailVerification is unused and not yet expired.
+func (v *EmailVerification) IsUsable() bool {
+	return !v.UsedAt.Valid && time.Now().Before(v.ExpiresAt)
+}
@@ -0,0 +1,137 @@
+package user
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var emailVerificationQueries = struct {
+	selectEmailVerification string
+	insertEmailVerification string
+	markUsed                string
+}{
+	selectEmailVerification: `
+		SELECT
+			id,
+			userId,
+			tokenHash,
+			expiresAt,
+			createdAt,
+			usedAt
+		FROM user_verifications`,
+
+	insertEmailVerification: `
+		INSERT INTO user_verifications (
+			id,
+			userId,
+			tokenHash,
+			expiresAt,
+			createdAt,
+			usedAt
+		) VALUES (
+			:id,
+			:userId,
+			:tokenHash,
+			:expiresAt,
+			:createdAt,
+			:usedAt)`,
+
+	markUsed: `
+		UPDATE user_verifications
+		SET usedAt = ?
+		WHERE id = ? AND usedAt IS NULL`,
+}
+
+// EmailVerificationRepository is the repository for EmailVerification data.
+type EmailVerificationRepository interface {
+	Create(verification EmailVerification) (err error)
+	MarkUsed(id uuid.UUID) (err error)
+	ResolveByTokenHash(tokenHash string) (verification EmailVerification, err error)
+}
+
+// EmailVerificationRepositoryMySQL is the MySQL-backed implementation of EmailVerificationRepository.
+type EmailVerificationRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideEmailVerificationRepositoryMySQL is the provider for this repository.
+func ProvideEmailVerificationRepositoryMySQL(db *infras.MySQLConn) *EmailVerificationRepositoryMySQL {
+	s := new(EmailVerificationRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// Create creates a new EmailVerification.
+func (r *EmailVerificationRepositoryMySQL) Create(verification EmailVerification) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := r.txCreate(tx, verification); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// MarkUsed marks an EmailVerification as used. It fails with a conflict if the
+// verification has already been used, so a token can only be redeemed once even
+// under concurrent requests.
+func (r *EmailVerificationRepositoryMySQL) MarkUsed(id uuid.UUID) (err error) {
+	result, err := r.DB.Write.Exec(emailVerificationQueries.markUsed, time.Now(), id.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.Conflict("use", "email verification", "already used")
+	}
+
+	return
+}
+
+// ResolveByTokenHash resolves an EmailVerification by the hash of its plaintext token.
+func (r *EmailVerificationRepositoryMySQL) ResolveByTokenHash(tokenHash string) (verification EmailVerification, err error) {
+	err = r.DB.Read.Get(
+		&verification,
+		emailVerificationQueries.selectEmailVerification+" WHERE tokenHash = ?",
+		tokenHash)
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("email verification")
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return
+}
+
+// internal methods
+
+// txCreate creates an EmailVerification transactionally given the *sqlx.Tx param.
+func (r *EmailVerificationRepositoryMySQL) txCreate(tx *sqlx.Tx, verification EmailVerification) (err error) {
+	stmt, err := tx.PrepareNamed(emailVerificationQueries.insertEmailVerification)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(verification)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
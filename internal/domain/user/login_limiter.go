@@ -0,0 +1,336 @@
+package user
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-redis/redis"
+)
+
+const (
+	loginFailuresKeyFormat = "login:failures:%s"
+	loginLockedKeyFormat   = "login:locked:%s"
+
+	defaultLockoutMaxAttempts        = 5
+	defaultLockoutIPMaxAttempts      = 50
+	defaultLockoutWindowSeconds      = 15 * 60
+	defaultLockoutBaseLockoutSeconds = 60
+	defaultLockoutMaxLockoutSeconds  = 60 * 60
+)
+
+// LoginAttemptStore keeps count of failed logins and of temporary locks, keyed
+// by an arbitrary subject such as a username or an IP address.
+type LoginAttemptStore interface {
+	LockedFor(key string) (remaining time.Duration, err error)
+	Lock(key string, duration time.Duration) (err error)
+	RegisterFailure(key string, ttl time.Duration) (failures int64, err error)
+	Reset(key string) (err error)
+}
+
+// ProvideLoginAttemptStore is the provider for LoginAttemptStore. It uses
+// Redis when a client is available and falls back to an in-memory store,
+// which is only suitable for a single instance.
+func ProvideLoginAttemptStore(client *redis.Client) LoginAttemptStore {
+	if client == nil {
+		return NewLoginAttemptStoreInMemory()
+	}
+
+	return NewLoginAttemptStoreRedis(client)
+}
+
+// LoginAttemptStoreRedis is the Redis-backed implementation of LoginAttemptStore.
+type LoginAttemptStoreRedis struct {
+	Client *redis.Client
+}
+
+// NewLoginAttemptStoreRedis creates a new LoginAttemptStoreRedis.
+func NewLoginAttemptStoreRedis(client *redis.Client) *LoginAttemptStoreRedis {
+	return &LoginAttemptStoreRedis{Client: client}
+}
+
+// LockedFor resolves how long a key stays locked, zero if it is not locked.
+func (s *LoginAttemptStoreRedis) LockedFor(key string) (remaining time.Duration, err error) {
+	remaining, err = s.Client.TTL(fmt.Sprintf(loginLockedKeyFormat, key)).Result()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	// TTL reports negative values for missing keys and keys without expiry
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return
+}
+
+// Lock locks a key for duration.
+func (s *LoginAttemptStoreRedis) Lock(key string, duration time.Duration) (err error) {
+	err = s.Client.Set(fmt.Sprintf(loginLockedKeyFormat, key), 1, duration).Err()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// RegisterFailure counts a failed login for a key. The count is forgotten
+// once no failure has been registered for ttl.
+func (s *LoginAttemptStoreRedis) RegisterFailure(key string, ttl time.Duration) (failures int64, err error) {
+	failuresKey := fmt.Sprintf(loginFailuresKeyFormat, key)
+
+	pipe := s.Client.TxPipeline()
+	incr := pipe.Incr(failuresKey)
+	pipe.Expire(failuresKey, ttl)
+	_, err = pipe.Exec()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return incr.Val(), nil
+}
+
+// Reset clears the failure count and lock of a key.
+func (s *LoginAttemptStoreRedis) Reset(key string) (err error) {
+	err = s.Client.Del(fmt.Sprintf(loginFailuresKeyFormat, key), fmt.Sprintf(loginLockedKeyFormat, key)).Err()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+type loginAttempt struct {
+	failures    int64
+	expiresAt   time.Time
+	lockedUntil time.Time
+}
+
+// LoginAttemptStoreInMemory is an in-memory implementation of
+// LoginAttemptStore, intended for tests and local development.
+type LoginAttemptStoreInMemory struct {
+	mu       sync.Mutex
+	attempts map[string]*loginAttempt
+}
+
+// NewLoginAttemptStoreInMemory creates a new LoginAttemptStoreInMemory.
+func NewLoginAttemptStoreInMemory() *LoginAttemptStoreInMemory {
+	return &LoginAttemptStoreInMemory{
+		attempts: make(map[string]*loginAttempt),
+	}
+}
+
+// LockedFor resolves how long a key stays locked, zero if it is not locked.
+func (s *LoginAttemptStoreInMemory) LockedFor(key string) (remaining time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return 0, nil
+	}
+
+	remaining = time.Until(attempt.lockedUntil)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return remaining, nil
+}
+
+// Lock locks a key for duration.
+func (s *LoginAttemptStoreInMemory) Lock(key string, duration time.Duration) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempt(key)
+	attempt.lockedUntil = time.Now().Add(duration)
+	if attempt.expiresAt.Before(attempt.lockedUntil) {
+		attempt.expiresAt = attempt.lockedUntil
+	}
+
+	return
+}
+
+// RegisterFailure counts a failed login for a key. The count is forgotten
+// once no failure has been registered for ttl.
+func (s *LoginAttemptStoreInMemory) RegisterFailure(key string, ttl time.Duration) (failures int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempt(key)
+	attempt.failures++
+	attempt.expiresAt = time.Now().Add(ttl)
+
+	return attempt.failures, nil
+}
+
+// Reset clears the failure count and lock of a key.
+func (s *LoginAttemptStoreInMemory) Reset(key string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return
+}
+
+// attempt resolves the live attempt for a key, dropping an expired one. The
+// caller must hold the lock.
+func (s *LoginAttemptStoreInMemory) attempt(key string) *loginAttempt {
+	attempt, ok := s.attempts[key]
+	if !ok || time.Now().After(attempt.expiresAt) {
+		attempt = &loginAttempt{}
+		s.attempts[key] = attempt
+	}
+
+	return attempt
+}
+
+// LoginLimiter throttles password guessing. Failed logins are counted per
+// username and per client IP; once a count reaches its threshold the subject
+// is locked, for a duration that doubles with every further failure up to a
+// maximum.
+type LoginLimiter struct {
+	Store         LoginAttemptStore
+	MaxAttempts   int64
+	IPMaxAttempts int64
+	Window        time.Duration
+	BaseLockout   time.Duration
+	MaxLockout    time.Duration
+}
+
+// ProvideLoginLimiter is the provider for LoginLimiter, loading its
+// thresholds from config.
+func ProvideLoginLimiter(store LoginAttemptStore, config *configs.Config) *LoginLimiter {
+	lockout := config.Auth.Lockout
+
+	l := &LoginLimiter{
+		Store:         store,
+		MaxAttempts:   orDefault(lockout.MaxAttempts, defaultLockoutMaxAttempts),
+		IPMaxAttempts: orDefault(lockout.IPMaxAttempts, defaultLockoutIPMaxAttempts),
+		Window:        time.Duration(orDefault(lockout.WindowSeconds, defaultLockoutWindowSeconds)) * time.Second,
+		BaseLockout:   time.Duration(orDefault(lockout.BaseLockoutSeconds, defaultLockoutBaseLockoutSeconds)) * time.Second,
+		MaxLockout:    time.Duration(orDefault(lockout.MaxLockoutSeconds, defaultLockoutMaxLockoutSeconds)) * time.Second,
+	}
+
+	return l
+}
+
+// Check fails with 423 Locked when the username is locked, and with 429 Too
+// Many Requests when the client IP is locked. It is meant to be called before
+// any password is hashed.
+func (l *LoginLimiter) Check(username string, ip string) (err error) {
+	remaining, err := l.Store.LockedFor(usernameKey(username))
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if remaining > 0 {
+		return failure.Locked(fmt.Sprintf("account is temporarily locked, retry in %d seconds", retrySeconds(remaining)))
+	}
+
+	if ip == "" {
+		return
+	}
+
+	remaining, err = l.Store.LockedFor(ipKey(ip))
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if remaining > 0 {
+		return failure.TooManyRequests(fmt.Sprintf("too many failed logins, retry in %d seconds", retrySeconds(remaining)))
+	}
+
+	return
+}
+
+// RegisterFailure counts a failed login for a username and client IP and
+// locks either of them once its threshold is reached.
+func (l *LoginLimiter) RegisterFailure(username string, ip string) (err error) {
+	err = l.registerFailure(usernameKey(username), l.MaxAttempts)
+	if err != nil || ip == "" {
+		return
+	}
+
+	return l.registerFailure(ipKey(ip), l.IPMaxAttempts)
+}
+
+// RegisterSuccess clears the failures of a username after a successful
+// login. Failures of the client IP are kept so that a valid account cannot be
+// used to reset the IP throttle.
+func (l *LoginLimiter) RegisterSuccess(username string) (err error) {
+	err = l.Store.Reset(usernameKey(username))
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	return
+}
+
+// Unlock clears the failures and lock of a username.
+func (l *LoginLimiter) Unlock(username string) (err error) {
+	return l.RegisterSuccess(username)
+}
+
+// LockoutDuration returns how long a subject is locked after failures failed
+// logins against a threshold of maxAttempts, zero below the threshold.
+func (l *LoginLimiter) LockoutDuration(failures int64, maxAttempts int64) time.Duration {
+	if failures < maxAttempts {
+		return 0
+	}
+
+	exponent := float64(failures - maxAttempts)
+	duration := float64(l.BaseLockout) * math.Pow(2, exponent)
+	if duration >= float64(l.MaxLockout) {
+		return l.MaxLockout
+	}
+
+	return time.Duration(duration)
+}
+
+func (l *LoginLimiter) registerFailure(key string, maxAttempts int64) (err error) {
+	// keep counting through the longest lock so that the next lock escalates
+	failures, err := l.Store.RegisterFailure(key, l.Window+l.MaxLockout)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	duration := l.LockoutDuration(failures, maxAttempts)
+	if duration == 0 {
+		return
+	}
+
+	err = l.Store.Lock(key, duration)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	return
+}
+
+func usernameKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func retrySeconds(remaining time.Duration) int64 {
+	return int64(math.Ceil(remaining.Seconds()))
+}
+
+func orDefault(value int64, fallback int64) int64 {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
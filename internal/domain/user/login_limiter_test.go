@@ -0,0 +1,67 @@
+package user_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoginLimiter() *user.LoginLimiter {
+	return &user.LoginLimiter{
+		Store:         user.NewLoginAttemptStoreInMemory(),
+		MaxAttempts:   3,
+		IPMaxAttempts: 5,
+		Window:        time.Minute,
+		BaseLockout:   time.Minute,
+		MaxLockout:    4 * time.Minute,
+	}
+}
+
+func TestLoginLimiter(t *testing.T) {
+	t.Run("lockoutDuration", func(t *testing.T) {
+		limiter := newLoginLimiter()
+
+		assert.Equal(t, time.Duration(0), limiter.LockoutDuration(2, 3))
+		assert.Equal(t, time.Minute, limiter.LockoutDuration(3, 3))
+		assert.Equal(t, 2*time.Minute, limiter.LockoutDuration(4, 3))
+		assert.Equal(t, 4*time.Minute, limiter.LockoutDuration(5, 3))
+		assert.Equal(t, 4*time.Minute, limiter.LockoutDuration(100, 3))
+	})
+
+	t.Run("locksUsername", func(t *testing.T) {
+		limiter := newLoginLimiter()
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, limiter.Check("Alice", "10.0.0.1"))
+			assert.NoError(t, limiter.RegisterFailure("Alice", "10.0.0.1"))
+		}
+
+		err := limiter.Check("alice", "10.0.0.2")
+		assert.Equal(t, http.StatusLocked, failure.GetCode(err))
+		assert.NoError(t, limiter.Check("bob", "10.0.0.2"))
+
+		assert.NoError(t, limiter.Unlock("alice"))
+		assert.NoError(t, limiter.Check("alice", "10.0.0.2"))
+	})
+
+	t.Run("locksIP", func(t *testing.T) {
+		limiter := newLoginLimiter()
+
+		for _, username := range []string{"a", "b", "c", "d", "e"} {
+			assert.NoError(t, limiter.RegisterFailure(username, "10.0.0.1"))
+		}
+
+		err := limiter.Check("f", "10.0.0.1")
+		assert.Equal(t, http.StatusTooManyRequests, failure.GetCode(err))
+
+		// a successful login does not reset the IP throttle
+		assert.NoError(t, limiter.RegisterSuccess("f"))
+		err = limiter.Check("f", "10.0.0.1")
+		assert.Equal(t, http.StatusTooManyRequests, failure.GetCode(err))
+		assert.NoError(t, limiter.Check("f", "10.0.0.2"))
+	})
+}
@@ -6,19 +6,37 @@ import (
 	"time"
 
 	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
-	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleTeacher = "teacher"
+	RoleStudent = "student"
+)
+
+const (
+	// StatusPending is the status of a user that has not verified its email yet.
+	StatusPending = "pending"
+	// StatusActive is the status of a user in good standing.
+	StatusActive = "active"
+	// StatusSuspended is the status of a user that may not sign in.
+	StatusSuspended = "suspended"
 )
 
 type User struct {
 	Id 			uuid.UUID 	`db:"id" validate:"required"`
 	Username 	string		`db:"username" validate:"required"`
 	Name 	 	string 		`db:"name" validate:"required"`
+	Email           null.String `db:"email"`
 	Password 	string 		`db:"password" validate:"required"`
 	Role 	 	string 		`db:"role" validate:"required"`
+	Status          string      `db:"status" validate:"required,oneof=pending active suspended"`
+	EmailVerifiedAt null.Time   `db:"emailVerifiedAt"`
 	AccessToken string 		`db:"-"`
 	CreatedAt   time.Time   `db:"createdAt"`
 	CreatedBy   uuid.UUID   `db:"createdBy"`
@@ -36,38 +54,107 @@ func (u User) MarshalJSON() ([]byte, error) {
 	return json.Marshal(u.ToResponseFormat())
 }
 
-func HashPassword(password string) (string, error) {
-    bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-    return string(bytes), err
+// SoftDelete marks a User as deleted by the acting user.
+func (u *User) SoftDelete(actorID uuid.UUID) (err error) {
+	if u.IsDeleted() {
+		return failure.Conflict("softDelete", "User", "already marked as deleted")
+	}
+
+	u.DeletedAt = null.TimeFrom(time.Now())
+	u.DeletedBy = nuuid.From(actorID)
+
+	return
 }
 
-func CheckPasswordHash(password, hash string) bool {
-    err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-    return err == nil
+// Restore clears the deleted mark of a User.
+func (u *User) Restore(actorID uuid.UUID) (err error) {
+	if !u.IsDeleted() {
+		return failure.Conflict("restore", "User", "not marked as deleted")
+	}
+
+	u.DeletedAt = null.Time{}
+	u.DeletedBy = nuuid.NUUID{}
+	u.UpdatedAt = null.TimeFrom(time.Now())
+	u.UpdatedBy = nuuid.From(actorID)
+
+	return
 }
 
-func (u *User) Update(req UserRequestFormat, user User) (err error) {
-	
+// IsActive checks whether a User has verified its account and is not suspended.
+func (u *User) IsActive() bool {
+	return u.Status == StatusActive
+}
+
+// IsSuspended checks whether a User has been suspended.
+func (u *User) IsSuspended() bool {
+	return u.Status == StatusSuspended
+}
+
+// Recipient returns the address notifications for a User are sent to, its
+// email when it has one and its username otherwise.
+func (u *User) Recipient() string {
+	if u.Email.Valid && u.Email.String != "" {
+		return u.Email.String
+	}
+	return u.Username
+}
+
+// Verify marks the email of a User as verified, activating a pending User.
+func (u *User) Verify() {
+	now := time.Now()
+	u.EmailVerifiedAt = null.TimeFrom(now)
+	if u.Status == StatusPending {
+		u.Status = StatusActive
+	}
+	u.UpdatedAt = null.TimeFrom(now)
+	u.UpdatedBy = nuuid.From(u.Id)
+}
+
+// ChangeStatus changes the status of a User on behalf of the acting user.
+func (u *User) ChangeStatus(status string, actorID uuid.UUID) (err error) {
+	u.Status = status
+	u.UpdatedAt = null.TimeFrom(time.Now())
+	u.UpdatedBy = nuuid.From(actorID)
+
+	return u.Validate()
+}
+
+// ChangeRole changes the role of a User on behalf of the acting user.
+func (u *User) ChangeRole(role string, actorID uuid.UUID) (err error) {
+	u.Role = role
+	u.UpdatedAt = null.TimeFrom(time.Now())
+	u.UpdatedBy = nuuid.From(actorID)
+
+	return u.Validate()
+}
+
+func (u *User) Update(req UpdateUserRequestFormat, user User) (err error) {
+
 	u.Username = req.Username
 	u.Name = req.Name
-	u.Password = req.Password
-	u.Role = req.Role
 	u.UpdatedAt = null.TimeFrom(time.Now())
 	u.UpdatedBy = nuuid.From(user.Id)
-	
+
 	err = u.Validate()
 	if err != nil {
 		log.Println(err.Error())
 		return
-	} 
-	
-	hashPassword, err := HashPassword(req.Password)
+	}
+
+	return
+}
+
+// ChangePassword replaces the password of a User with the hash of password.
+func (u *User) ChangePassword(password string, hasher PasswordHasher, actorID uuid.UUID) (err error) {
+	hashPassword, err := hasher.Hash(password)
 	if err != nil {
-		log.Println(err.Error())
-		return 
+		return
 	}
+
 	u.Password = hashPassword
-	
+	u.UpdatedAt = null.TimeFrom(time.Now())
+	u.UpdatedBy = nuuid.From(actorID)
+
 	return
 }
 
@@ -76,14 +163,16 @@ func (u *User) Validate() (err error) {
 	return validator.Struct(u)
 }
 
-func (u User) NewFromRequestFormat(req UserRequestFormat) (newUser User, err error) {
+func (u User) NewFromRequestFormat(req UserRequestFormat, hasher PasswordHasher) (newUser User, err error) {
 	userID, _ := uuid.NewV4()
 	newUser = User{
 		Id: userID,
 		Username: req.Username,
 		Name: req.Name,
+		Email:     null.NewString(req.Email, req.Email != ""),
 		Password: req.Password,
 		Role: req.Role,
+		Status:    StatusPending,
 		CreatedAt:   time.Now(),
 		CreatedBy:   userID,
 	}
@@ -93,7 +182,7 @@ func (u User) NewFromRequestFormat(req UserRequestFormat) (newUser User, err err
 		return
 	} 
 	
-	passwordHashed, err := HashPassword(req.Password)
+	passwordHashed, err := hasher.Hash(req.Password)
 	if err != nil {
 		log.Println(err.Error())
 		return
@@ -112,7 +201,10 @@ func (u User) ToResponseFormat() UserResponseFormat {
 		Id: u.Id,
 		Username: u.Username,
 		Name: u.Name,
+		Email:           u.Email,
 		Role: u.Role,
+		Status:          u.Status,
+		EmailVerifiedAt: u.EmailVerifiedAt,
 		AccessToken: u.AccessToken,
 		CreatedAt: u.CreatedAt,
 		CreatedBy: u.CreatedBy,
@@ -125,21 +217,34 @@ func (u User) ToResponseFormat() UserResponseFormat {
 	return resp
 }
 
-
-
+// UserRequestFormat is the public signup payload. Privileged roles can only
+// be granted through the admin API.
 type UserRequestFormat struct {
-	Username 	string  `json:"username" validate:"required"`
+	Username string `json:"username" validate:"required,username"`
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=teacher student"`
+}
+
+// UpdateUserRequestFormat is the profile update payload. Passwords are changed
+// through ChangePasswordRequestFormat and roles through the admin API; Role is
+// only accepted when it matches the current role.
+type UpdateUserRequestFormat struct {
+	Username string `json:"username" validate:"required,username"`
 	Name 	    string  `json:"name" validate:"required"`
-	Password 	string  `json:"password" validate:"required"`
-	Role 	    string  `json:"role" validate:"required"`
+	Role     string `json:"role"`
 }
 
 type UserResponseFormat struct {
 	Id uuid.UUID 			`json:"id"`
 	Username 	string 		`json:"username"`
 	Name 	 	string 		`json:"name"`
+	Email           null.String `json:"email"`
 	Role 	 	string 		`json:"role"`
-	AccessToken string 		`json:"accessToken"`
+	Status          string      `json:"status"`
+	EmailVerifiedAt null.Time   `json:"emailVerifiedAt"`
+	AccessToken     string      `json:"accessToken,omitempty"`
 	CreatedAt   time.Time   `json:"createdAt"`
 	CreatedBy   uuid.UUID   `json:"createdBy"`
 	UpdatedAt   null.Time   `json:"updatedAt,omitempty"`
@@ -154,7 +259,8 @@ type Login struct {
 	Password 	string  	
 	User		User
 	AccessToken string
-	
+	RefreshToken   string
+	ChallengeToken string
 }
 
 func (l Login) MarshalJSON() ([]byte, error) {
@@ -178,7 +284,9 @@ func (l Login) ToResponseFormat() LoginResponseFormat {
 	
 	resp := LoginResponseFormat{
 		AccessToken: l.AccessToken,
-
+		RefreshToken:      l.RefreshToken,
+		TwoFactorRequired: l.ChallengeToken != "",
+		ChallengeToken:    l.ChallengeToken,
 	}
 	return resp
 }
@@ -186,11 +294,73 @@ func (l Login) ToResponseFormat() LoginResponseFormat {
 type LoginRequestFormat struct {
 	Username 	string 		`json:"username" validate:"required"`
 	Password 	string  	`json:"password" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
 	
+type LogoutRequestFormat struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 type LoginResponseFormat struct {
-	AccessToken string 		`json:"accessToken"`
+	AccessToken       string `json:"accessToken,omitempty"`
+	RefreshToken      string `json:"refreshToken,omitempty"`
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
 }
 
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
 
+// UserFilter holds the criteria for listing users.
+type UserFilter struct {
+	Role           string
+	UsernamePrefix string
+	CreatedFrom    null.Time
+	CreatedTo      null.Time
+	IncludeDeleted bool
+	Page           int
+	PageSize       int
+}
+
+// Normalize applies the default and maximum page size to this filter.
+func (f *UserFilter) Normalize() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+
+	if f.PageSize < 1 {
+		f.PageSize = defaultUserPageSize
+	}
+
+	if f.PageSize > maxUserPageSize {
+		f.PageSize = maxUserPageSize
+	}
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (f *UserFilter) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// UserPage is a single page of users along with the total number of matches.
+type UserPage struct {
+	Users    []User `json:"users"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+	Total    int    `json:"total"`
+}
+
+type ChangeStatusRequestFormat struct {
+	Status string `json:"status" validate:"required,oneof=pending active suspended"`
+}
+
+type ResendVerificationRequestFormat struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type ChangeRoleRequestFormat struct {
+	Role string `json:"role" validate:"required,oneof=admin teacher student"`
+}
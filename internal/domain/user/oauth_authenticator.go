@@ -0,0 +1,88 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+)
+
+// OAuthUserAuthenticator lets the OAuth2 grants sign users in. It
+// implements oauth.UserAuthenticator on top of UserService, so the grant
+// follows the same lockout and account status rules as our login.
+type OAuthUserAuthenticator struct {
+	UserService UserService
+}
+
+// ProvideOAuthUserAuthenticator is the provider for OAuthUserAuthenticator.
+func ProvideOAuthUserAuthenticator(userService UserService) *OAuthUserAuthenticator {
+	return &OAuthUserAuthenticator{UserService: userService}
+}
+
+// Authenticate checks the credentials of a user and returns the user's ID.
+// Lockouts, two-factor authentication and account status are reported with
+// their own errors; any other client error means invalid credentials.
+func (a *OAuthUserAuthenticator) Authenticate(username string, password string, clientIP string) (userID string, err error) {
+	id, err := a.UserService.AuthenticatePassword(username, password, clientIP)
+	if err != nil {
+		return "", oauthError(err, oauth.ErrorInvalidPassword)
+	}
+
+	return id.String(), nil
+}
+
+// ResolveRole resolves the role of the user an access token acts for. A user
+// that may no longer sign in is reported as ErrorUserNotAllowed.
+func (a *OAuthUserAuthenticator) ResolveRole(userID string) (role string, err error) {
+	id, err := uuid.FromString(userID)
+	if err != nil {
+		return "", errors.New(oauth.ErrorUserNotAllowed)
+	}
+
+	role, err = a.UserService.ResolveRole(id)
+	if err != nil {
+		return "", oauthError(err, oauth.ErrorUserNotAllowed)
+	}
+
+	return
+}
+
+// Verify checks that the user a refresh token was issued to may still sign
+// in. Every reason they may not is reported as ErrorUserNotAllowed.
+func (a *OAuthUserAuthenticator) Verify(userID string) (err error) {
+	id, err := uuid.FromString(userID)
+	if err != nil {
+		return errors.New(oauth.ErrorUserNotAllowed)
+	}
+
+	err = a.UserService.VerifyUser(id)
+	if err != nil {
+		return oauthError(err, oauth.ErrorUserNotAllowed)
+	}
+
+	return
+}
+
+// oauthError maps an error of UserService to the OAuth2 error of the same
+// kind. Client errors without one of their own become fallback; server errors
+// are passed through.
+func oauthError(err error, fallback string) error {
+	if err == errTwoFactorRequired {
+		return errors.New(oauth.ErrorTwoFactorRequired)
+	}
+
+	switch code := failure.GetCode(err); {
+	case code == http.StatusLocked:
+		return errors.New(oauth.ErrorUserLocked)
+	case code == http.StatusTooManyRequests:
+		return errors.New(oauth.ErrorTooManyAttempts)
+	case code == http.StatusForbidden:
+		return errors.New(oauth.ErrorUserNotAllowed)
+	case code < http.StatusInternalServerError:
+		return errors.New(fallback)
+	}
+
+	return err
+}
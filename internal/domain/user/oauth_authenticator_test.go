@@ -0,0 +1,39 @@
+package user_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type userServiceStub struct {
+	user.UserService
+	err error
+}
+
+func (s *userServiceStub) AuthenticatePassword(username string, password string, clientIP string) (uuid.UUID, error) {
+	return uuid.Nil, s.err
+}
+
+func TestOAuthUserAuthenticatorErrors(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want string
+	}{
+		{failure.BadRequestFromString("Password False!"), oauth.ErrorInvalidPassword},
+		{failure.NotFound("User"), oauth.ErrorInvalidPassword},
+		{failure.Forbidden("account is suspended"), oauth.ErrorUserNotAllowed},
+		{failure.Locked("account is temporarily locked"), oauth.ErrorUserLocked},
+		{failure.TooManyRequests("too many failed logins"), oauth.ErrorTooManyAttempts},
+		{errors.New("connection refused"), "connection refused"},
+	} {
+		authenticator := user.ProvideOAuthUserAuthenticator(&userServiceStub{err: test.err})
+		_, err := authenticator.Authenticate("student", "secret", "127.0.0.1")
+		assert.EqualError(t, err, test.want)
+	}
+}
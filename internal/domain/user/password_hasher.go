@@ -0,0 +1,236 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordAlgorithmBcrypt hashes passwords with bcrypt.
+	PasswordAlgorithmBcrypt = "bcrypt"
+	// PasswordAlgorithmArgon2id hashes passwords with argon2id.
+	PasswordAlgorithmArgon2id = "argon2id"
+
+	defaultBcryptCost          = 12
+	defaultArgon2idMemory      = 64 * 1024
+	defaultArgon2idIterations  = 3
+	defaultArgon2idParallelism = 2
+	defaultArgon2idSaltLength  = 16
+	defaultArgon2idKeyLength   = 32
+)
+
+// ErrUnknownPasswordHash is returned when a stored hash uses an unsupported format.
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes and verifies passwords. Hashes encode their algorithm
+// and parameters, so hashes made with older settings keep verifying and can be
+// upgraded once NeedsRehash reports them.
+type PasswordHasher interface {
+	Hash(password string) (hash string, err error)
+	NeedsRehash(hash string) bool
+	Verify(password string, hash string) (match bool, err error)
+}
+
+// Argon2idParams are the cost parameters of argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHasherImpl hashes new passwords with the configured algorithm and
+// verifies both bcrypt and argon2id hashes.
+type PasswordHasherImpl struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParams
+}
+
+// ProvidePasswordHasher is the provider for PasswordHasher.
+func ProvidePasswordHasher(config *configs.Config) PasswordHasher {
+	hasher, err := NewPasswordHasherFromConfig(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed configuring password hashing")
+	}
+
+	return hasher
+}
+
+// NewPasswordHasherFromConfig creates a PasswordHasherImpl from config,
+// applying defaults for unset parameters.
+func NewPasswordHasherFromConfig(config *configs.Config) (*PasswordHasherImpl, error) {
+	password := config.Auth.Password
+
+	h := &PasswordHasherImpl{
+		Algorithm:  strings.ToLower(password.Algorithm),
+		BcryptCost: password.BcryptCost,
+		Argon2id: Argon2idParams{
+			Memory:      password.Argon2id.Memory,
+			Iterations:  password.Argon2id.Iterations,
+			Parallelism: password.Argon2id.Parallelism,
+			SaltLength:  password.Argon2id.SaltLength,
+			KeyLength:   password.Argon2id.KeyLength,
+		},
+	}
+
+	if h.Algorithm == "" {
+		h.Algorithm = PasswordAlgorithmBcrypt
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = defaultBcryptCost
+	}
+	if h.Argon2id.Memory == 0 {
+		h.Argon2id.Memory = defaultArgon2idMemory
+	}
+	if h.Argon2id.Iterations == 0 {
+		h.Argon2id.Iterations = defaultArgon2idIterations
+	}
+	if h.Argon2id.Parallelism == 0 {
+		h.Argon2id.Parallelism = defaultArgon2idParallelism
+	}
+	if h.Argon2id.SaltLength == 0 {
+		h.Argon2id.SaltLength = defaultArgon2idSaltLength
+	}
+	if h.Argon2id.KeyLength == 0 {
+		h.Argon2id.KeyLength = defaultArgon2idKeyLength
+	}
+
+	switch h.Algorithm {
+	case PasswordAlgorithmBcrypt:
+		if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case PasswordAlgorithmArgon2id:
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm %s", h.Algorithm)
+	}
+
+	return h, nil
+}
+
+// Hash hashes a password with the configured algorithm.
+func (h *PasswordHasherImpl) Hash(password string) (hash string, err error) {
+	if h.Algorithm == PasswordAlgorithmArgon2id {
+		return h.hashArgon2id(password)
+	}
+
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+	return string(bytes), err
+}
+
+// Verify checks a password against a bcrypt or argon2id hash.
+func (h *PasswordHasherImpl) Verify(password string, hash string) (match bool, err error) {
+	if isArgon2idHash(hash) {
+		params, salt, key, err := decodeArgon2idHash(hash)
+		if err != nil {
+			return false, err
+		}
+
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	}
+
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return false, ErrUnknownPasswordHash
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// NeedsRehash checks whether a hash was made with another algorithm or other
+// parameters than the configured ones.
+func (h *PasswordHasherImpl) NeedsRehash(hash string) bool {
+	if isArgon2idHash(hash) {
+		if h.Algorithm != PasswordAlgorithmArgon2id {
+			return true
+		}
+
+		params, _, key, err := decodeArgon2idHash(hash)
+		if err != nil {
+			return true
+		}
+
+		return params.Memory != h.Argon2id.Memory ||
+			params.Iterations != h.Argon2id.Iterations ||
+			params.Parallelism != h.Argon2id.Parallelism ||
+			uint32(len(key)) != h.Argon2id.KeyLength
+	}
+
+	if h.Algorithm != PasswordAlgorithmBcrypt {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.BcryptCost
+}
+
+// hashArgon2id hashes a password in the PHC string format,
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+func (h *PasswordHasherImpl) hashArgon2id(password string) (hash string, err error) {
+	salt := make([]byte, h.Argon2id.SaltLength)
+	if _, err = rand.Read(salt); err != nil {
+		return
+	}
+
+	p := h.Argon2id
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func isArgon2idHash(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func decodeArgon2idHash(hash string) (params Argon2idParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		err = ErrUnknownPasswordHash
+		return
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		err = ErrUnknownPasswordHash
+		return
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		err = ErrUnknownPasswordHash
+		return
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		err = ErrUnknownPasswordHash
+		return
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		err = ErrUnknownPasswordHash
+		return
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return
+}
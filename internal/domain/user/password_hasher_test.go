@@ -0,0 +1,74 @@
+package user_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPasswordHasher(t *testing.T, algorithm string) *user.PasswordHasherImpl {
+	config := &configs.Config{}
+	config.Auth.Password.Algorithm = algorithm
+	config.Auth.Password.BcryptCost = 4
+	config.Auth.Password.Argon2id.Memory = 1024
+	config.Auth.Password.Argon2id.Iterations = 1
+
+	hasher, err := user.NewPasswordHasherFromConfig(config)
+	assert.NoError(t, err)
+	return hasher
+}
+
+func TestPasswordHasher(t *testing.T) {
+	bcryptHasher := newPasswordHasher(t, user.PasswordAlgorithmBcrypt)
+	argon2idHasher := newPasswordHasher(t, user.PasswordAlgorithmArgon2id)
+
+	t.Run("verify", func(t *testing.T) {
+		for _, hasher := range []*user.PasswordHasherImpl{bcryptHasher, argon2idHasher} {
+			hash, err := hasher.Hash("secret")
+			assert.NoError(t, err)
+
+			match, err := hasher.Verify("secret", hash)
+			assert.NoError(t, err)
+			assert.True(t, match)
+
+			match, err = hasher.Verify("wrong", hash)
+			assert.NoError(t, err)
+			assert.False(t, match)
+
+			assert.False(t, hasher.NeedsRehash(hash))
+		}
+	})
+
+	t.Run("encodesParameters", func(t *testing.T) {
+		hash, err := argon2idHasher.Hash("secret")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=2$"))
+	})
+
+	t.Run("needsRehash", func(t *testing.T) {
+		bcryptHash, err := bcryptHasher.Hash("secret")
+		assert.NoError(t, err)
+		argon2idHash, err := argon2idHasher.Hash("secret")
+		assert.NoError(t, err)
+
+		// hashes made with another algorithm keep verifying
+		match, err := argon2idHasher.Verify("secret", bcryptHash)
+		assert.NoError(t, err)
+		assert.True(t, match)
+
+		assert.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+		assert.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+
+		stronger := newPasswordHasher(t, user.PasswordAlgorithmBcrypt)
+		stronger.BcryptCost = 5
+		assert.True(t, stronger.NeedsRehash(bcryptHash))
+	})
+
+	t.Run("rejectsUnknownHash", func(t *testing.T) {
+		_, err := bcryptHasher.Verify("secret", "plaintext")
+		assert.Equal(t, user.ErrUnknownPasswordHash, err)
+	})
+}
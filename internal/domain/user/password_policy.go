@@ -0,0 +1,154 @@
+package user
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPasswordMinLength = 8
+	// bcrypt ignores everything past 72 bytes
+	defaultPasswordMaxLength = 72
+)
+
+// PasswordPolicy holds the rules a new password must satisfy.
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// CommonPasswords holds lowercased passwords that are rejected outright.
+	CommonPasswords map[string]bool
+}
+
+// ProvidePasswordPolicy is the provider for PasswordPolicy, loading its rules
+// and the common password list from config.
+func ProvidePasswordPolicy(config *configs.Config) *PasswordPolicy {
+	policy, err := NewPasswordPolicyFromConfig(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed loading password policy")
+	}
+
+	return policy
+}
+
+// NewPasswordPolicyFromConfig creates a PasswordPolicy from config.
+func NewPasswordPolicyFromConfig(config *configs.Config) (*PasswordPolicy, error) {
+	password := config.Auth.Password
+
+	p := &PasswordPolicy{
+		MinLength:        password.MinLength,
+		MaxLength:        password.MaxLength,
+		RequireUppercase: password.RequireUppercase,
+		RequireLowercase: password.RequireLowercase,
+		RequireDigit:     password.RequireDigit,
+		RequireSymbol:    password.RequireSymbol,
+		CommonPasswords:  make(map[string]bool),
+	}
+
+	if p.MinLength <= 0 {
+		p.MinLength = defaultPasswordMinLength
+	}
+	if p.MaxLength <= 0 {
+		p.MaxLength = defaultPasswordMaxLength
+	}
+
+	if password.CommonPasswordsFile == "" {
+		return p, nil
+	}
+
+	f, err := os.Open(password.CommonPasswordsFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening common passwords file: %v", err)
+	}
+	defer f.Close()
+
+	p.CommonPasswords, err = ReadCommonPasswords(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading common passwords file: %v", err)
+	}
+
+	return p, nil
+}
+
+// ReadCommonPasswords reads a password list with one password per line.
+// Blank lines and lines starting with # are skipped.
+func ReadCommonPasswords(r io.Reader) (map[string]bool, error) {
+	passwords := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = true
+	}
+
+	return passwords, scanner.Err()
+}
+
+// Validate checks password against the policy for the user with username,
+// failing with a bad request that lists every rule it breaks.
+func (p *PasswordPolicy) Validate(password string, username string) (err error) {
+	var problems []string
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		problems = append(problems, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		problems = append(problems, "must not be the same as the username")
+	}
+	if p.CommonPasswords[strings.ToLower(password)] {
+		problems = append(problems, "is too common")
+	}
+
+	if len(problems) > 0 {
+		return failure.BadRequest(errors.New("password " + strings.Join(problems, ", ")))
+	}
+
+	return
+}
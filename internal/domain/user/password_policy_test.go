@@ -0,0 +1,51 @@
+package user_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	common, err := user.ReadCommonPasswords(strings.NewReader("# top passwords\nPassword1\n\nqwerty123\n"))
+	assert.NoError(t, err)
+	assert.Len(t, common, 2)
+
+	policy := &user.PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        72,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		CommonPasswords:  common,
+	}
+
+	assert.NoError(t, policy.Validate("Correct1Horse", "alice"))
+	assert.Error(t, policy.Validate("Sh0rt", "alice"))
+	assert.Error(t, policy.Validate("nouppercase1", "alice"))
+	assert.Error(t, policy.Validate("NoDigitsHere", "alice"))
+	assert.Error(t, policy.Validate("password1", "alice"))
+	assert.Error(t, policy.Validate("Alice12345", "alice12345"))
+	assert.Error(t, policy.Validate(strings.Repeat("Aa1", 25), "alice"))
+}
+
+func TestUserRequestFormat(t *testing.T) {
+	validate := func(username string, role string) error {
+		return shared.GetValidator().Struct(user.UserRequestFormat{
+			Username: username,
+			Name:     "Alice",
+			Password: "Correct1Horse",
+			Role:     role,
+		})
+	}
+
+	assert.NoError(t, validate("alice.smith_01", user.RoleStudent))
+	assert.NoError(t, validate("bob", user.RoleTeacher))
+	assert.Error(t, validate("alice", user.RoleAdmin))
+	assert.Error(t, validate(" alice", user.RoleStudent))
+	assert.Error(t, validate("al", user.RoleStudent))
+	assert.Error(t, validate("alice smith", user.RoleStudent))
+}
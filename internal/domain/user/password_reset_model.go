@@ -0,0 +1,73 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+const passwordResetTokenBytes = 32
+
+// PasswordReset is a single-use, time-limited token that allows a user to set
+// a new password without knowing the current one. Only the SHA-256 hash of the
+// token is persisted.
+type PasswordReset struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"userId"`
+	TokenHash string    `db:"tokenHash"`
+	ExpiresAt time.Time `db:"expiresAt"`
+	CreatedAt time.Time `db:"createdAt"`
+	UsedAt    null.Time `db:"usedAt"`
+	Token     string    `db:"-"`
+}
+
+// NewPasswordReset creates a new PasswordReset for a user along with its
+// plaintext token.
+func NewPasswordReset(userID uuid.UUID, ttl time.Duration) (reset PasswordReset, err error) {
+	raw := make([]byte, passwordResetTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now()
+	reset = PasswordReset{
+		ID:        id,
+		UserID:    userID,
+		TokenHash: HashRefreshToken(plain),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		Token:     plain,
+	}
+
+	return
+}
+
+// IsUsable checks whether this PasswordReset is unused and not yet expired.
+func (p *PasswordReset) IsUsable() bool {
+	return !p.UsedAt.Valid && time.Now().Before(p.ExpiresAt)
+}
+
+type ChangePasswordRequestFormat struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+	ClientIP        string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+type ForgotPasswordRequestFormat struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type ResetPasswordRequestFormat struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
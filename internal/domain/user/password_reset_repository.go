@@ -0,0 +1,137 @@
+package user
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var passwordResetQueries = struct {
+	selectPasswordReset string
+	insertPasswordReset string
+	markUsed            string
+}{
+	selectPasswordReset: `
+		SELECT
+			id,
+			userId,
+			tokenHash,
+			expiresAt,
+			createdAt,
+			usedAt
+		FROM user_password_resets`,
+
+	insertPasswordReset: `
+		INSERT INTO user_password_resets (
+			id,
+			userId,
+			tokenHash,
+			expiresAt,
+			createdAt,
+			usedAt
+		) VALUES (
+			:id,
+			:userId,
+			:tokenHash,
+			:expiresAt,
+			:createdAt,
+			:usedAt)`,
+
+	markUsed: `
+		UPDATE user_password_resets
+		SET usedAt = ?
+		WHERE id = ? AND usedAt IS NULL`,
+}
+
+// PasswordResetRepository is the repository for PasswordReset data.
+type PasswordResetRepository interface {
+	Create(reset PasswordReset) (err error)
+	MarkUsed(id uuid.UUID) (err error)
+	ResolveByTokenHash(tokenHash string) (reset PasswordReset, err error)
+}
+
+// PasswordResetRepositoryMySQL is the MySQL-backed implementation of PasswordResetRepository.
+type PasswordResetRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvidePasswordResetRepositoryMySQL is the provider for this repository.
+func ProvidePasswordResetRepositoryMySQL(db *infras.MySQLConn) *PasswordResetRepositoryMySQL {
+	s := new(PasswordResetRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// Create creates a new PasswordReset.
+func (r *PasswordResetRepositoryMySQL) Create(reset PasswordReset) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := r.txCreate(tx, reset); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// MarkUsed marks a PasswordReset as used. It fails with a conflict if the
+// reset has already been used, so a token can only be redeemed once even
+// under concurrent requests.
+func (r *PasswordResetRepositoryMySQL) MarkUsed(id uuid.UUID) (err error) {
+	result, err := r.DB.Write.Exec(passwordResetQueries.markUsed, time.Now(), id.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.Conflict("use", "password reset", "already used")
+	}
+
+	return
+}
+
+// ResolveByTokenHash resolves a PasswordReset by the hash of its plaintext token.
+func (r *PasswordResetRepositoryMySQL) ResolveByTokenHash(tokenHash string) (reset PasswordReset, err error) {
+	err = r.DB.Read.Get(
+		&reset,
+		passwordResetQueries.selectPasswordReset+" WHERE tokenHash = ?",
+		tokenHash)
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("password reset")
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return
+}
+
+// internal methods
+
+// txCreate creates a PasswordReset transactionally given the *sqlx.Tx param.
+func (r *PasswordResetRepositoryMySQL) txCreate(tx *sqlx.Tx, reset PasswordReset) (err error) {
+	stmt, err := tx.PrepareNamed(passwordResetQueries.insertPasswordReset)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(reset)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+const refreshTokenBytes = 32
+
+// RefreshToken is an opaque, rotating token that can be exchanged for a new
+// access token. Only the SHA-256 hash of the token is persisted. Tokens that
+// descend from the same login share a FamilyID so that reuse of a rotated
+// token can revoke the whole chain.
+type RefreshToken struct {
+	ID         uuid.UUID   `db:"id"`
+	FamilyID   uuid.UUID   `db:"familyId"`
+	UserID     uuid.UUID   `db:"userId"`
+	TokenHash  string      `db:"tokenHash"`
+	ExpiresAt  time.Time   `db:"expiresAt"`
+	CreatedAt  time.Time   `db:"createdAt"`
+	RevokedAt  null.Time   `db:"revokedAt"`
+	ReplacedBy nuuid.NUUID `db:"replacedBy"`
+	Token      string      `db:"-"`
+}
+
+// NewRefreshToken creates a new RefreshToken for a user in the given family
+// and returns it along with its plaintext value.
+func NewRefreshToken(userID uuid.UUID, familyID uuid.UUID, ttl time.Duration) (token RefreshToken, err error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now()
+	token = RefreshToken{
+		ID:        id,
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: HashRefreshToken(plain),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		Token:     plain,
+	}
+
+	return
+}
+
+// HashRefreshToken returns the hex-encoded SHA-256 hash of a plaintext refresh token.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsExpired checks whether this RefreshToken has passed its expiry time.
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsRevoked checks whether this RefreshToken has been rotated or revoked.
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt.Valid
+}
+
+// RefreshTokenRequestFormat represents the request body for exchanging a refresh token.
+type RefreshTokenRequestFormat struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+	ClientIP     string `json:"-"`
+}
@@ -0,0 +1,192 @@
+package user
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var refreshTokenQueries = struct {
+	selectRefreshToken string
+	insertRefreshToken string
+	rotateRefreshToken string
+	revokeFamily       string
+	revokeByUserID     string
+}{
+	selectRefreshToken: `
+		SELECT
+			id,
+			familyId,
+			userId,
+			tokenHash,
+			expiresAt,
+			createdAt,
+			revokedAt,
+			replacedBy
+		FROM user_refresh_tokens`,
+
+	insertRefreshToken: `
+		INSERT INTO user_refresh_tokens (
+			id,
+			familyId,
+			userId,
+			tokenHash,
+			expiresAt,
+			createdAt,
+			revokedAt,
+			replacedBy
+		) VALUES (
+			:id,
+			:familyId,
+			:userId,
+			:tokenHash,
+			:expiresAt,
+			:createdAt,
+			:revokedAt,
+			:replacedBy)`,
+
+	rotateRefreshToken: `
+		UPDATE user_refresh_tokens
+		SET
+			revokedAt = ?,
+			replacedBy = ?
+		WHERE id = ? AND revokedAt IS NULL`,
+
+	revokeFamily: `
+		UPDATE user_refresh_tokens
+		SET revokedAt = ?
+		WHERE familyId = ? AND revokedAt IS NULL`,
+
+	revokeByUserID: `
+		UPDATE user_refresh_tokens
+		SET revokedAt = ?
+		WHERE userId = ? AND revokedAt IS NULL`,
+}
+
+// RefreshTokenRepository is the repository for RefreshToken data.
+type RefreshTokenRepository interface {
+	Create(token RefreshToken) (err error)
+	ResolveByTokenHash(tokenHash string) (token RefreshToken, err error)
+	RevokeByUserID(userID uuid.UUID) (err error)
+	RevokeFamily(familyID uuid.UUID) (err error)
+	Rotate(current RefreshToken, next RefreshToken) (err error)
+}
+
+// RefreshTokenRepositoryMySQL is the MySQL-backed implementation of RefreshTokenRepository.
+type RefreshTokenRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideRefreshTokenRepositoryMySQL is the provider for this repository.
+func ProvideRefreshTokenRepositoryMySQL(db *infras.MySQLConn) *RefreshTokenRepositoryMySQL {
+	s := new(RefreshTokenRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// Create creates a new RefreshToken.
+func (r *RefreshTokenRepositoryMySQL) Create(token RefreshToken) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := r.txCreate(tx, token); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// ResolveByTokenHash resolves a RefreshToken by the hash of its plaintext value.
+func (r *RefreshTokenRepositoryMySQL) ResolveByTokenHash(tokenHash string) (token RefreshToken, err error) {
+	err = r.DB.Read.Get(
+		&token,
+		refreshTokenQueries.selectRefreshToken+" WHERE tokenHash = ?",
+		tokenHash)
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("refresh token")
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return
+}
+
+// RevokeByUserID revokes every active RefreshToken that belongs to a user.
+func (r *RefreshTokenRepositoryMySQL) RevokeByUserID(userID uuid.UUID) (err error) {
+	_, err = r.DB.Write.Exec(refreshTokenQueries.revokeByUserID, time.Now(), userID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// RevokeFamily revokes every active RefreshToken that belongs to a token family.
+func (r *RefreshTokenRepositoryMySQL) RevokeFamily(familyID uuid.UUID) (err error) {
+	_, err = r.DB.Write.Exec(refreshTokenQueries.revokeFamily, time.Now(), familyID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// Rotate marks the current RefreshToken as replaced and stores its successor
+// in a single transaction. It fails with a conflict if the current token has
+// already been rotated by a concurrent request.
+func (r *RefreshTokenRepositoryMySQL) Rotate(current RefreshToken, next RefreshToken) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		result, err := tx.Exec(
+			refreshTokenQueries.rotateRefreshToken,
+			time.Now(),
+			next.ID.String(),
+			current.ID.String())
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			e <- err
+			return
+		}
+
+		if affected == 0 {
+			e <- failure.Conflict("rotate", "refresh token", "already rotated")
+			return
+		}
+
+		if err := r.txCreate(tx, next); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// internal methods
+
+// txCreate creates a RefreshToken transactionally given the *sqlx.Tx param.
+func (r *RefreshTokenRepositoryMySQL) txCreate(tx *sqlx.Tx, token RefreshToken) (err error) {
+	stmt, err := tx.PrepareNamed(refreshTokenQueries.insertRefreshToken)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(token)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
@@ -0,0 +1,159 @@
+package user_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+// refreshTokenRepositoryInMemory keeps refresh tokens by their hash.
+type refreshTokenRepositoryInMemory struct {
+	tokens map[string]user.RefreshToken
+}
+
+func (r *refreshTokenRepositoryInMemory) Create(token user.RefreshToken) error {
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *refreshTokenRepositoryInMemory) ResolveByTokenHash(tokenHash string) (user.RefreshToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return token, failure.NotFound("refresh token")
+	}
+	return token, nil
+}
+
+func (r *refreshTokenRepositoryInMemory) RevokeByUserID(userID uuid.UUID) error {
+	for hash, token := range r.tokens {
+		if token.UserID == userID && !token.IsRevoked() {
+			token.RevokedAt = null.TimeFrom(time.Now())
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (r *refreshTokenRepositoryInMemory) RevokeFamily(familyID uuid.UUID) error {
+	for hash, token := range r.tokens {
+		if token.FamilyID == familyID && !token.IsRevoked() {
+			token.RevokedAt = null.TimeFrom(time.Now())
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (r *refreshTokenRepositoryInMemory) Rotate(current user.RefreshToken, next user.RefreshToken) error {
+	stored := r.tokens[current.TokenHash]
+	if stored.IsRevoked() {
+		return failure.Conflict("rotate", "refresh token", "already rotated")
+	}
+
+	stored.RevokedAt = null.TimeFrom(time.Now())
+	stored.ReplacedBy = nuuid.From(next.ID)
+	r.tokens[current.TokenHash] = stored
+	r.tokens[next.TokenHash] = next
+	return nil
+}
+
+type userRepositoryStub struct {
+	user.UserRepository
+	user  user.User
+	taken bool
+}
+
+func (r *userRepositoryStub) ExistsByUsername(username string) (bool, error) {
+	return r.taken, nil
+}
+
+func (r *userRepositoryStub) ResolveByID(id uuid.UUID) (user.User, error) {
+	return r.user, nil
+}
+
+type sessionRepositoryStub struct {
+	user.SessionRepository
+}
+
+func (r *sessionRepositoryStub) Touch(id uuid.UUID, tokenID string, ipAddress string) error {
+	return nil
+}
+
+func TestRefreshToken(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	familyID, _ := uuid.NewV4()
+
+	newService := func(t *testing.T) (*user.UserServiceImpl, *refreshTokenRepositoryInMemory, string) {
+		keySet, err := jwtmodel.NewKeySet([]byte("secret"), "")
+		assert.NoError(t, err)
+
+		tokens := &refreshTokenRepositoryInMemory{tokens: map[string]user.RefreshToken{}}
+		first, err := user.NewRefreshToken(userID, familyID, time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, tokens.Create(first))
+
+		s := &user.UserServiceImpl{
+			UserRepository:         &userRepositoryStub{user: user.User{Id: userID, Username: "student", Role: "student", Status: user.StatusActive}},
+			RefreshTokenRepository: tokens,
+			SessionRepository:      &sessionRepositoryStub{},
+			TokenRevocationStore:   user.NewTokenRevocationStoreInMemory(),
+			KeySet:                 keySet,
+			Config:                 &configs.Config{},
+		}
+
+		return s, tokens, first.Token
+	}
+
+	t.Run("rotates the presented token", func(t *testing.T) {
+		s, tokens, first := newService(t)
+
+		login, err := s.RefreshToken(user.RefreshTokenRequestFormat{RefreshToken: first})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, login.AccessToken)
+		assert.NotEqual(t, first, login.RefreshToken)
+
+		rotated := tokens.tokens[user.HashRefreshToken(first)]
+		assert.True(t, rotated.IsRevoked())
+		assert.True(t, rotated.ReplacedBy.Valid)
+
+		next := tokens.tokens[user.HashRefreshToken(login.RefreshToken)]
+		assert.Equal(t, familyID, next.FamilyID)
+		assert.False(t, next.IsRevoked())
+
+		_, err = s.RefreshToken(user.RefreshTokenRequestFormat{RefreshToken: login.RefreshToken})
+		assert.NoError(t, err)
+	})
+
+	t.Run("revokes the family when a rotated token is reused", func(t *testing.T) {
+		s, tokens, first := newService(t)
+
+		login, err := s.RefreshToken(user.RefreshTokenRequestFormat{RefreshToken: first})
+		assert.NoError(t, err)
+
+		_, err = s.RefreshToken(user.RefreshTokenRequestFormat{RefreshToken: first})
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err))
+
+		for _, token := range tokens.tokens {
+			assert.True(t, token.IsRevoked())
+		}
+
+		_, err = s.RefreshToken(user.RefreshTokenRequestFormat{RefreshToken: login.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err))
+	})
+
+	t.Run("rejects unknown tokens", func(t *testing.T) {
+		s, _, _ := newService(t)
+
+		_, err := s.RefreshToken(user.RefreshTokenRequestFormat{RefreshToken: "unknown"})
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err))
+	})
+}
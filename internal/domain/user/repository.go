@@ -2,14 +2,19 @@ package user
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-sql-driver/mysql"
 	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+// mysqlErrDuplicateEntry is the MySQL error number for unique key violations.
+const mysqlErrDuplicateEntry = 1062
 
 var userQueries = struct {
 	selectUser		string
@@ -22,8 +27,11 @@ var userQueries = struct {
 		id,
 		username, 
 		name, 
+		email,
 		password, 
 		role, 
+		status,
+		emailVerifiedAt,
 		createdAt,
 		createdBy,
 		updatedAt,
@@ -34,8 +42,11 @@ var userQueries = struct {
 		:id,
 		:username, 
 		:name, 
+		:email,
 		:password, 
 		:role, 
+		:status,
+		:emailVerifiedAt,
 		:createdAt,
 		:createdBy,
 		:updatedAt,
@@ -49,8 +60,11 @@ var userQueries = struct {
 	SET
 	  	username = :username,
 		name = :name,
+		email = :email,
 		password = :password,
 		role = :role,
+		status = :status,
+		emailVerifiedAt = :emailVerifiedAt,
 		createdAt = :createdAt,
 		createdBy = :createdBy,
 		updatedAt = :updatedAt,
@@ -63,6 +77,9 @@ var userQueries = struct {
 type UserRepository interface {
 	Create(user User) (err error)
 	ExistsByID(id uuid.UUID) (exists bool, err error)
+	ExistsByUsername(username string) (exists bool, err error)
+	ResolveAll(filter UserFilter) (users []User, total int, err error)
+	ResolveByID(id uuid.UUID) (user User, err error)
 	ResolveByUsername(username string) (user User, err error)
 	Update(user User) (err error)
 
@@ -92,6 +109,18 @@ func (r *UserRepositoryMySQL) Create(user User) (err error)  {
 		return
 	}
 
+	exists, err = r.ExistsByUsername(user.Username)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if exists {
+		err = failure.Conflict("create", "User", "username is already taken")
+		logger.ErrorWithStack(err)
+		return
+	}
+
 	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
 		if err := r.txCreate(tx, user); err != nil {
 			e <- err
@@ -137,6 +166,83 @@ func (r *UserRepositoryMySQL) ExistsByID(id uuid.UUID) (exists bool, err error)
 	return
 }
 
+// ExistsByUsername checks whether a user, including a deleted one, holds username.
+func (r *UserRepositoryMySQL) ExistsByUsername(username string) (exists bool, err error) {
+	err = r.DB.Read.Get(
+		&exists,
+		"SELECT COUNT(id) FROM users WHERE username = ?", username)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveAll resolves a page of users matching filter, along with the total
+// number of matching users.
+func (r *UserRepositoryMySQL) ResolveAll(filter UserFilter) (users []User, total int, err error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deletedAt IS NULL")
+	}
+
+	if filter.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, filter.Role)
+	}
+
+	if filter.UsernamePrefix != "" {
+		conditions = append(conditions, "username LIKE ?")
+		args = append(args, shared.EscapeLike(filter.UsernamePrefix)+"%")
+	}
+
+	if filter.CreatedFrom.Valid {
+		conditions = append(conditions, "createdAt >= ?")
+		args = append(args, filter.CreatedFrom.Time)
+	}
+
+	if filter.CreatedTo.Valid {
+		conditions = append(conditions, "createdAt < ?")
+		args = append(args, filter.CreatedTo.Time)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	err = r.DB.Read.Get(&total, "SELECT COUNT(id) FROM users"+where, args...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	users = make([]User, 0)
+	err = r.DB.Read.Select(
+		&users,
+		userQueries.selectUser+where+" ORDER BY createdAt DESC, id LIMIT ? OFFSET ?",
+		append(args, filter.PageSize, filter.Offset())...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+func (r *UserRepositoryMySQL) ResolveByID(id uuid.UUID) (user User, err error) {
+	err = r.DB.Read.Get(
+		&user,
+		userQueries.selectUser+" WHERE id = ?",
+		id.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("User")
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
 
 func (r *UserRepositoryMySQL) ResolveByUsername(username string) (user User, err error) {
 	err = r.DB.Read.Get(
@@ -162,6 +268,9 @@ func (r *UserRepositoryMySQL) txCreate(tx *sqlx.Tx, user User) (err error) {
 
 	_, err = stmt.Exec(user)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			err = failure.Conflict("create", "User", "username or email is already taken")
+		}
 		logger.ErrorWithStack(err)
 	}
 
@@ -179,8 +288,17 @@ func (r *UserRepositoryMySQL) txUpdate(tx *sqlx.Tx, user User) (err error) {
 
 	_, err = stmt.Exec(user)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			err = failure.Conflict("update", "User", "username or email is already taken")
+		}
 		logger.ErrorWithStack(err)
 	}
 
 	return
 }
+
+// isDuplicateEntry checks whether err is a MySQL unique key violation.
+func isDuplicateEntry(err error) bool {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	return ok && mysqlErr.Number == mysqlErrDuplicateEntry
+}
@@ -1,36 +1,114 @@
 package user
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/encryption"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/notifier"
+	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt"
 )
 
+const (
+	defaultAccessTokenExpirySeconds   = 60 * 60
+	defaultRefreshTokenExpirySeconds  = 60 * 60 * 24 * 30
+	defaultPasswordResetExpirySeconds = 60 * 60
+	defaultVerificationExpirySeconds  = 60 * 60 * 24
+)
+
+// errTwoFactorRequired is returned by AuthenticatePassword for users with
+// two-factor authentication enabled.
+var errTwoFactorRequired = failure.Forbidden("two-factor authentication is required")
+
 type UserService interface {
+	AuthenticatePassword(username string, password string, clientIP string) (id uuid.UUID, err error)
+	ChangePassword(claims *jwtmodel.Claims, requestFormat ChangePasswordRequestFormat) (login Login, err error)
+	ChangeRole(id uuid.UUID, requestFormat ChangeRoleRequestFormat, actorID uuid.UUID) (user User, err error)
+	ChangeStatus(id uuid.UUID, requestFormat ChangeStatusRequestFormat, actorID uuid.UUID) (user User, err error)
 	Create(requestFormat UserRequestFormat) (user User, err error)
+	DisableTwoFactor(claims *jwtmodel.Claims, requestFormat TwoFactorDisableRequestFormat) (err error)
+	EnableTwoFactor(claims *jwtmodel.Claims, requestFormat TwoFactorCodeRequestFormat) (recoveryCodes RecoveryCodesResponseFormat, err error)
+	EnrollTwoFactor(claims *jwtmodel.Claims) (enrollment TwoFactorEnrollResponseFormat, err error)
+	ForgotPassword(requestFormat ForgotPasswordRequestFormat) (err error)
 	Login(requestFormat LoginRequestFormat) (login Login, err error)
+	LoginTwoFactor(requestFormat LoginTwoFactorRequestFormat) (login Login, err error)
+	Logout(claims *jwtmodel.Claims, requestFormat LogoutRequestFormat) (err error)
+	LogoutAll(claims *jwtmodel.Claims) (err error)
+	RefreshToken(requestFormat RefreshTokenRequestFormat) (login Login, err error)
+	RegenerateRecoveryCodes(claims *jwtmodel.Claims, requestFormat TwoFactorCodeRequestFormat) (recoveryCodes RecoveryCodesResponseFormat, err error)
+	ResendVerification(requestFormat ResendVerificationRequestFormat) (err error)
+	ResetPassword(requestFormat ResetPasswordRequestFormat) (err error)
+	ResolveAll(filter UserFilter) (page UserPage, err error)
+	ResolveByID(id uuid.UUID) (user User, err error)
 	ResolveByUsername(username string) (user User, err error)
-	Update(username string,requestFormat UserRequestFormat) (user User, err error)
+	ResolveProfile(id uuid.UUID) (user User, err error)
+	ResolveRole(id uuid.UUID) (role string, err error)
+	ResolveSessions(claims *jwtmodel.Claims) (sessions []Session, err error)
+	Restore(id uuid.UUID, actorID uuid.UUID) (user User, err error)
+	RevokeSession(claims *jwtmodel.Claims, id uuid.UUID) (err error)
+	SoftDelete(id uuid.UUID, actorID uuid.UUID) (user User, err error)
+	Unlock(id uuid.UUID) (err error)
+	Update(id uuid.UUID, requestFormat UpdateUserRequestFormat) (user User, err error)
+	VerifyClaims(claims *jwtmodel.Claims) (err error)
+	VerifyEmail(token string) (user User, err error)
+	VerifyUser(id uuid.UUID) (err error)
 }
 
 type UserServiceImpl struct {
-	UserRepository UserRepository
-	Config *configs.Config
+	UserRepository          UserRepository
+	RefreshTokenRepository  RefreshTokenRepository
+	SessionRepository       SessionRepository
+	PasswordResetRepository PasswordResetRepository
+	VerificationRepository  EmailVerificationRepository
+	TwoFactorRepository     TwoFactorRepository
+	TwoFactorCipher         *encryption.AESGCM
+	TokenRevocationStore    TokenRevocationStore
+	LoginLimiter            *LoginLimiter
+	PasswordHasher          PasswordHasher
+	PasswordPolicy          *PasswordPolicy
+	Notifier                notifier.Notifier
+	KeySet                  *jwtmodel.KeySet
+	Config                  *configs.Config
 }
 
-func ProvideUserServiceImpl(userRepository UserRepository, config *configs.Config)  *UserServiceImpl {
+func ProvideUserServiceImpl(userRepository UserRepository, refreshTokenRepository RefreshTokenRepository, sessionRepository SessionRepository, passwordResetRepository PasswordResetRepository, verificationRepository EmailVerificationRepository, twoFactorRepository TwoFactorRepository, twoFactorCipher *encryption.AESGCM, tokenRevocationStore TokenRevocationStore, loginLimiter *LoginLimiter, passwordHasher PasswordHasher, passwordPolicy *PasswordPolicy, notifier notifier.Notifier, keySet *jwtmodel.KeySet, config *configs.Config) *UserServiceImpl {
 	s := new(UserServiceImpl)
 	s.UserRepository = userRepository
+	s.RefreshTokenRepository = refreshTokenRepository
+	s.SessionRepository = sessionRepository
+	s.PasswordResetRepository = passwordResetRepository
+	s.VerificationRepository = verificationRepository
+	s.TwoFactorRepository = twoFactorRepository
+	s.TwoFactorCipher = twoFactorCipher
+	s.Notifier = notifier
+	s.TokenRevocationStore = tokenRevocationStore
+	s.LoginLimiter = loginLimiter
+	s.PasswordHasher = passwordHasher
+	s.PasswordPolicy = passwordPolicy
+	s.KeySet = keySet
 	s.Config = config
 
 	return s
 }
 
-func (s *UserServiceImpl) Create(requestFormat UserRequestFormat) (user User, err error)  {
-	user, err = user.NewFromRequestFormat(requestFormat)
+func (s *UserServiceImpl) Create(requestFormat UserRequestFormat) (user User, err error) {
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return user, failure.BadRequest(err)
+	}
+
+	err = s.PasswordPolicy.Validate(requestFormat.Password, requestFormat.Username)
+	if err != nil {
+		return
+	}
+
+	user, err = user.NewFromRequestFormat(requestFormat, s.PasswordHasher)
 	if err != nil {
 		return user, failure.BadRequest(err)
 	}
@@ -40,62 +118,338 @@ func (s *UserServiceImpl) Create(requestFormat UserRequestFormat) (user User, er
 		return
 	}
 
+	// the account is created either way; the user can ask for another token
+	if err := s.sendVerification(user); err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	if s.checkStatus(user) != nil {
+		return
+	}
+
 	user.AccessToken, err = s.GenerateJWT(user)
 	if err != nil {
 		return user, failure.InternalError(err)
-	} 
+	}
 
 	return
 }
 
-
-func (s *UserServiceImpl) Login(requestFormat LoginRequestFormat) (login Login, err error)  {
+func (s *UserServiceImpl) Login(requestFormat LoginRequestFormat) (login Login, err error) {
 	login, err = login.NewFromRequestFormat(requestFormat)
 	if err != nil {
 		return login, failure.BadRequest(err)
 	}
-	var user User
-	user, err = s.UserRepository.ResolveByUsername(login.Username)
+
+	user, err := s.verifyCredentials(login.Username, login.Password, requestFormat.ClientIP)
 	if err != nil {
-		return
+		return Login{}, err
 	}
 
-	
-	
 	login.User = user
-	
-	if match := CheckPasswordHash(login.Password, login.User.Password); !match {
-		return Login{}, failure.BadRequestFromString("Password False!")
+
+	twoFactorEnabled, err := s.isTwoFactorEnabled(user.Id)
+	if err != nil {
+		return Login{}, err
 	}
 
-	login.AccessToken, err = s.GenerateJWT(user)
+	if twoFactorEnabled {
+		login.ChallengeToken, err = s.generateChallengeToken(user)
+		if err != nil {
+			return Login{}, failure.InternalError(err)
+		}
+		return
+	}
+
+	err = s.LoginLimiter.RegisterSuccess(login.Username)
 	if err != nil {
-		return login, failure.InternalError(err)
-	} 
+		return Login{}, err
+	}
+
+	return s.issueTokens(user, ClientInfo{IPAddress: requestFormat.ClientIP, UserAgent: requestFormat.UserAgent})
+}
+
+// AuthenticatePassword checks the credentials of a user signing in without
+// our login page, such as through the OAuth2 password grant, and returns the
+// ID of the user. Users with two-factor authentication enabled cannot sign in
+// this way, since there is no step to ask for their code.
+func (s *UserServiceImpl) AuthenticatePassword(username string, password string, clientIP string) (id uuid.UUID, err error) {
+	user, err := s.verifyCredentials(username, password, clientIP)
+	if err != nil {
+		return
+	}
+
+	twoFactorEnabled, err := s.isTwoFactorEnabled(user.Id)
+	if err != nil {
+		return
+	}
+
+	if twoFactorEnabled {
+		return id, errTwoFactorRequired
+	}
+
+	err = s.LoginLimiter.RegisterSuccess(username)
+	if err != nil {
+		return
+	}
+
+	return user.Id, nil
+}
+
+// VerifyUser checks that a user may still sign in, for tokens issued outside
+// our login such as OAuth2 refresh tokens. Deleted users are unauthorized and
+// suspended or unverified users are forbidden, as at login.
+func (s *UserServiceImpl) VerifyUser(id uuid.UUID) (err error) {
+	_, err = s.resolveSignedInUser(id)
+	return
+}
+
+// ResolveRole resolves the role of a user that may still sign in, for OAuth2
+// access tokens acting for the user. It fails like VerifyUser.
+func (s *UserServiceImpl) ResolveRole(id uuid.UUID) (role string, err error) {
+	user, err := s.resolveSignedInUser(id)
+	if err != nil {
+		return
+	}
+
+	return user.Role, nil
+}
+
+// resolveSignedInUser resolves a user that may still sign in.
+func (s *UserServiceImpl) resolveSignedInUser(id uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.ResolveByID(id)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.Unauthorized("user not found")
+		}
+		return
+	}
+
+	if user.IsDeleted() {
+		return User{}, failure.Unauthorized("user not found")
+	}
+
+	err = s.checkStatus(user)
+	if err != nil {
+		return User{}, err
+	}
 
 	return
+}
+
+// Logout revokes the access token described by claims and the session it
+// was issued for. For tokens issued without a session, the refresh token
+// family of the given refresh token is revoked instead.
+func (s *UserServiceImpl) Logout(claims *jwtmodel.Claims, requestFormat LogoutRequestFormat) (err error) {
+	err = s.TokenRevocationStore.Revoke(claims.TokenID(), time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if sessionID, parseErr := uuid.FromString(claims.SessionID); parseErr == nil {
+		err = s.revokeSession(sessionID)
+		if err != nil {
+			return
+		}
+	}
+
+	if requestFormat.RefreshToken == "" {
+		return
+	}
+
+	token, err := s.RefreshTokenRepository.ResolveByTokenHash(HashRefreshToken(requestFormat.RefreshToken))
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = nil
+		}
+		return
+	}
+
+	if token.UserID != claims.UserId {
+		return
+	}
+
+	return s.RefreshTokenRepository.RevokeFamily(token.FamilyID)
+}
 
+// LogoutAll revokes every access and refresh token held by the user described
+// by claims by bumping their token version.
+func (s *UserServiceImpl) LogoutAll(claims *jwtmodel.Claims) (err error) {
+	return s.revokeAllTokens(claims.UserId)
 }
 
+// VerifyClaims checks that an access token with valid signature and expiry
+// has not been revoked since it was issued, either on its own or through its
+// session.
+func (s *UserServiceImpl) VerifyClaims(claims *jwtmodel.Claims) (err error) {
+	// the same rules, and status codes, as at login
+	err = s.checkStatus(User{Status: claims.Status})
+	if err != nil {
+		return
+	}
+
+	err = s.verifyNotRevoked(claims)
+	if err != nil {
+		return
+	}
+
+	return s.verifySessionActive(claims)
+}
+
+// verifyNotRevoked checks that a token has neither been revoked on its own
+// nor through its user's token version.
+func (s *UserServiceImpl) verifyNotRevoked(claims *jwtmodel.Claims) (err error) {
+	revoked, err := s.TokenRevocationStore.IsRevoked(claims.TokenID())
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if revoked {
+		return failure.Unauthorized("token has been revoked")
+	}
+
+	version, err := s.TokenRevocationStore.TokenVersion(claims.UserId)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if claims.TokenVersion < version {
+		return failure.Unauthorized("token has been revoked")
+	}
+
+	return
+}
+
+// RefreshToken exchanges a valid refresh token for a new access and refresh
+// token pair. The presented token is rotated; presenting an already rotated
+// token is treated as theft and revokes every token in its family.
+func (s *UserServiceImpl) RefreshToken(requestFormat RefreshTokenRequestFormat) (login Login, err error) {
+	current, err := s.RefreshTokenRepository.ResolveByTokenHash(HashRefreshToken(requestFormat.RefreshToken))
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.Unauthorized("invalid refresh token")
+		}
+		return
+	}
+
+	if current.IsRevoked() {
+		err = s.RefreshTokenRepository.RevokeFamily(current.FamilyID)
+		if err != nil {
+			return
+		}
+		return login, failure.Unauthorized("refresh token reuse detected")
+	}
+
+	if current.IsExpired() {
+		return login, failure.Unauthorized("refresh token expired")
+	}
+
+	user, err := s.UserRepository.ResolveByID(current.UserID)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		err = s.RefreshTokenRepository.RevokeFamily(current.FamilyID)
+		if err != nil {
+			return
+		}
+		return login, failure.Unauthorized("invalid refresh token")
+	}
+
+	if statusErr := s.checkStatus(user); statusErr != nil {
+		err = s.RefreshTokenRepository.RevokeFamily(current.FamilyID)
+		if err != nil {
+			return
+		}
+		return login, statusErr
+	}
+
+	next, err := NewRefreshToken(user.Id, current.FamilyID, s.refreshTokenExpiry())
+	if err != nil {
+		return login, failure.InternalError(err)
+	}
+
+	err = s.RefreshTokenRepository.Rotate(current, next)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusConflict {
+			err = failure.Unauthorized("refresh token reuse detected")
+		}
+		return
+	}
+
+	login.User = user
+	login.Username = user.Username
+	login.RefreshToken = next.Token
+
+	var tokenID string
+	login.AccessToken, tokenID, err = s.generateAccessToken(user, current.FamilyID.String())
+	if err != nil {
+		return Login{}, failure.InternalError(err)
+	}
+
+	err = s.SessionRepository.Touch(current.FamilyID, tokenID, requestFormat.ClientIP)
+	if err != nil {
+		return Login{}, err
+	}
 
+	return
+}
 
-func (s *UserServiceImpl) ResolveByUsername(username string) (user User, err error)  {
+func (s *UserServiceImpl) ResolveByUsername(username string) (user User, err error) {
 	user, err = s.UserRepository.ResolveByUsername(username)
 
 	if user.IsDeleted() {
 		return user, failure.NotFound("User")
 	}
 
+	return
+}
+
+// ResolveProfile resolves the own account of the signed-in user with the given
+// ID. A deleted user is not found.
+func (s *UserServiceImpl) ResolveProfile(id uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return User{}, failure.NotFound("User")
+	}
 
 	return
 }
 
-func (s *UserServiceImpl) Update(username string,requestFormat UserRequestFormat) (user User, err error)  {
-	user, err = s.UserRepository.ResolveByUsername(username)
+// Update updates the own account of the signed-in user with the given ID. A new
+// username must not be held by any other user, including a deleted one.
+func (s *UserServiceImpl) Update(id uuid.UUID, requestFormat UpdateUserRequestFormat) (user User, err error) {
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return user, failure.BadRequest(err)
+	}
+
+	user, err = s.ResolveProfile(id)
 	if err != nil {
 		return
 	}
 
+	if requestFormat.Role != "" && requestFormat.Role != user.Role {
+		return user, failure.Forbidden("role can only be changed by an admin")
+	}
+
+	if requestFormat.Username != user.Username {
+		var exists bool
+		exists, err = s.UserRepository.ExistsByUsername(requestFormat.Username)
+		if err != nil {
+			return User{}, err
+		}
+
+		if exists {
+			return User{}, failure.Conflict("update", "User", "username is already taken")
+		}
+	}
+
 	err = user.Update(requestFormat, user)
 	if err != nil {
 		return
@@ -103,31 +457,567 @@ func (s *UserServiceImpl) Update(username string,requestFormat UserRequestFormat
 
 	err = s.UserRepository.Update(user)
 
+	return
+}
+
+// ChangePassword changes the password of the user described by claims after
+// checking the current one. Every existing token of the user is revoked and a
+// fresh token pair is returned so the calling client stays signed in.
+func (s *UserServiceImpl) ChangePassword(claims *jwtmodel.Claims, requestFormat ChangePasswordRequestFormat) (login Login, err error) {
+	user, err := s.UserRepository.ResolveByID(claims.UserId)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return login, failure.NotFound("User")
+	}
+
+	match, err := s.PasswordHasher.Verify(requestFormat.CurrentPassword, user.Password)
+	if err != nil {
+		return login, failure.InternalError(err)
+	}
+
+	if !match {
+		return login, failure.BadRequestFromString("Password False!")
+	}
+
+	err = s.PasswordPolicy.Validate(requestFormat.NewPassword, user.Username)
+	if err != nil {
+		return
+	}
+
+	err = user.ChangePassword(requestFormat.NewPassword, s.PasswordHasher, user.Id)
+	if err != nil {
+		return login, failure.InternalError(err)
+	}
+
+	err = s.UserRepository.Update(user)
+	if err != nil {
+		return
+	}
+
+	err = s.revokeAllTokens(user.Id)
+	if err != nil {
+		return
+	}
+
+	return s.issueTokens(user, ClientInfo{IPAddress: requestFormat.ClientIP, UserAgent: requestFormat.UserAgent})
+}
+
+// ForgotPassword issues a password reset token and delivers it through the
+// Notifier. It succeeds for unknown or deleted users as well, so the response
+// cannot be used to find out which usernames exist.
+func (s *UserServiceImpl) ForgotPassword(requestFormat ForgotPasswordRequestFormat) (err error) {
+	user, err := s.UserRepository.ResolveByUsername(requestFormat.Username)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = nil
+		}
+		return
+	}
+
+	if user.IsDeleted() {
+		return
+	}
+
+	reset, err := NewPasswordReset(user.Id, s.passwordResetExpiry())
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	err = s.PasswordResetRepository.Create(reset)
+	if err != nil {
+		return
+	}
+
+	err = s.Notifier.Notify(notifier.Message{
+		Recipient: user.Recipient(),
+		Subject:   "Password reset",
+		Body:      "Use this token to reset your password: " + reset.Token,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return failure.InternalError(err)
+	}
+
+	return
+}
+
+// ResetPassword sets a new password using a token issued by ForgotPassword.
+// The token can only be used once, and every existing token of the user is
+// revoked afterwards.
+func (s *UserServiceImpl) ResetPassword(requestFormat ResetPasswordRequestFormat) (err error) {
+	reset, err := s.PasswordResetRepository.ResolveByTokenHash(HashRefreshToken(requestFormat.Token))
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.BadRequestFromString("invalid or expired reset token")
+		}
+		return
+	}
+
+	if !reset.IsUsable() {
+		return failure.BadRequestFromString("invalid or expired reset token")
+	}
+
+	user, err := s.UserRepository.ResolveByID(reset.UserID)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return failure.BadRequestFromString("invalid or expired reset token")
+	}
+
+	err = s.PasswordPolicy.Validate(requestFormat.NewPassword, user.Username)
+	if err != nil {
+		return
+	}
+
+	err = s.PasswordResetRepository.MarkUsed(reset.ID)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusConflict {
+			err = failure.BadRequestFromString("invalid or expired reset token")
+		}
+		return
+	}
+
+	err = user.ChangePassword(requestFormat.NewPassword, s.PasswordHasher, user.Id)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	err = s.UserRepository.Update(user)
+	if err != nil {
+		return
+	}
+
+	return s.revokeAllTokens(user.Id)
+}
+
+// VerifyEmail redeems a verification token issued at signup, marking the
+// user's email as verified and activating a pending account.
+func (s *UserServiceImpl) VerifyEmail(token string) (user User, err error) {
+	verification, err := s.VerificationRepository.ResolveByTokenHash(HashRefreshToken(token))
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.BadRequestFromString("invalid or expired verification token")
+		}
+		return
+	}
+
+	if !verification.IsUsable() {
+		return user, failure.BadRequestFromString("invalid or expired verification token")
+	}
+
+	user, err = s.UserRepository.ResolveByID(verification.UserID)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return User{}, failure.BadRequestFromString("invalid or expired verification token")
+	}
+
+	err = s.VerificationRepository.MarkUsed(verification.ID)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusConflict {
+			err = failure.BadRequestFromString("invalid or expired verification token")
+		}
+		return
+	}
+
+	user.Verify()
+	err = s.UserRepository.Update(user)
+	return
+}
+
+// ResendVerification issues another verification token for an unverified
+// user. Like ForgotPassword, it succeeds for unknown users as well.
+func (s *UserServiceImpl) ResendVerification(requestFormat ResendVerificationRequestFormat) (err error) {
+	user, err := s.UserRepository.ResolveByUsername(requestFormat.Username)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = nil
+		}
+		return
+	}
+
+	if user.IsDeleted() || user.EmailVerifiedAt.Valid {
+		return
+	}
+
+	err = s.sendVerification(user)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	return
+}
+
+// ChangeStatus changes the status of a User, e.g. to suspend it. Existing
+// tokens are revoked so they cannot outlive a suspension.
+func (s *UserServiceImpl) ChangeStatus(id uuid.UUID, requestFormat ChangeStatusRequestFormat, actorID uuid.UUID) (user User, err error) {
+	if id == actorID {
+		return user, failure.Conflict("changeStatus", "User", "cannot change your own status")
+	}
+
+	user, err = s.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return user, failure.NotFound("User")
+	}
+
+	err = user.ChangeStatus(requestFormat.Status, actorID)
+	if err != nil {
+		return user, failure.BadRequest(err)
+	}
+
+	err = s.UserRepository.Update(user)
+	if err != nil {
+		return
+	}
+
+	err = s.revokeAllTokens(user.Id)
+	return
+}
+
+// ResolveAll resolves a page of users, including deleted ones when asked to.
+func (s *UserServiceImpl) ResolveAll(filter UserFilter) (page UserPage, err error) {
+	filter.Normalize()
+
+	users, total, err := s.UserRepository.ResolveAll(filter)
+	if err != nil {
+		return
+	}
+
+	page = UserPage{
+		Users:    users,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}
+
+	return
+}
+
+// ResolveByID resolves a User by its ID, regardless of whether it is deleted.
+func (s *UserServiceImpl) ResolveByID(id uuid.UUID) (user User, err error) {
+	return s.UserRepository.ResolveByID(id)
+}
+
+// SoftDelete marks a User as deleted and revokes all of its tokens.
+func (s *UserServiceImpl) SoftDelete(id uuid.UUID, actorID uuid.UUID) (user User, err error) {
+	if id == actorID {
+		return user, failure.Conflict("softDelete", "User", "cannot delete yourself")
+	}
+
+	user, err = s.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	err = user.SoftDelete(actorID)
+	if err != nil {
+		return
+	}
+
+	err = s.UserRepository.Update(user)
+	if err != nil {
+		return
+	}
+
+	err = s.revokeAllTokens(user.Id)
+	return
+}
+
+// Restore clears the deleted mark of a User.
+func (s *UserServiceImpl) Restore(id uuid.UUID, actorID uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	err = user.Restore(actorID)
+	if err != nil {
+		return
+	}
+
+	err = s.UserRepository.Update(user)
+	return
+}
+
+// ChangeRole changes the role of a User. Tokens issued with the old role are
+// revoked so the new role takes effect immediately.
+func (s *UserServiceImpl) ChangeRole(id uuid.UUID, requestFormat ChangeRoleRequestFormat, actorID uuid.UUID) (user User, err error) {
+	if id == actorID {
+		return user, failure.Conflict("changeRole", "User", "cannot change your own role")
+	}
+
+	user, err = s.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return user, failure.NotFound("User")
+	}
+
+	err = user.ChangeRole(requestFormat.Role, actorID)
+	if err != nil {
+		return user, failure.BadRequest(err)
+	}
+
+	err = s.UserRepository.Update(user)
+	if err != nil {
+		return
+	}
 
+	err = s.revokeAllTokens(user.Id)
 	return
 }
 
+// Unlock clears the failed logins and lockout of a User.
+func (s *UserServiceImpl) Unlock(id uuid.UUID) (err error) {
+	user, err := s.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
 
+	return s.LoginLimiter.Unlock(user.Username)
+}
 
+// GenerateJWT signs an access token for a user that is not tied to a session.
+func (s *UserServiceImpl) GenerateJWT(user User) (string, error) {
+	tokenString, _, err := s.generateAccessToken(user, "")
+	return tokenString, err
+}
+
+// generateAccessToken signs an access token for a user and the session with
+// sessionID, returning the token along with its jti.
+func (s *UserServiceImpl) generateAccessToken(user User, sessionID string) (tokenString string, tokenID string, err error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 
-func (s *UserServiceImpl) GenerateJWT(user User) (string, error)  {
-	secret := configs.Get().App.JWTSecret
+	version, err := s.TokenRevocationStore.TokenVersion(user.Id)
+	if err != nil {
+		return
+	}
 
 	claims := jwtmodel.Claims{
-		UserId: user.Id,
-		Username: user.Username,
-		Role: user.Role,
+		UserId:       user.Id,
+		Username:     user.Username,
+		Role:         user.Role,
+		Status:       user.Status,
+		TokenVersion: version,
+		SessionID:    sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 1).Unix(),
-			Issuer: "evermos",
+			Id:        id.String(),
+			ExpiresAt: time.Now().Add(s.accessTokenExpiry()).Unix(),
+			Issuer:    "evermos",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err = s.KeySet.Sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+
+	return tokenString, id.String(), nil
+}
+
+// verifyCredentials resolves the user signing in with a username and
+// password, counting failures towards the login lockout, and checks that the
+// account may sign in.
+func (s *UserServiceImpl) verifyCredentials(username string, password string, clientIP string) (user User, err error) {
+	err = s.LoginLimiter.Check(username, clientIP)
+	if err != nil {
+		return
+	}
+
+	user, err = s.UserRepository.ResolveByUsername(username)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			if limitErr := s.LoginLimiter.RegisterFailure(username, clientIP); limitErr != nil {
+				return User{}, limitErr
+			}
+		}
+		return
+	}
+
+	if user.IsDeleted() {
+		return User{}, failure.NotFound("User")
+	}
+
+	match, err := s.PasswordHasher.Verify(password, user.Password)
+	if err != nil {
+		return User{}, failure.InternalError(err)
+	}
+
+	if !match {
+		err = s.LoginLimiter.RegisterFailure(username, clientIP)
+		if err != nil {
+			return User{}, err
+		}
+		return User{}, failure.BadRequestFromString("Password False!")
+	}
+
+	s.rehashPassword(&user, password)
+
+	err = s.checkStatus(user)
+	if err != nil {
+		return User{}, err
+	}
+
+	return
+}
+
+// rehashPassword upgrades the stored hash of a User after a successful login
+// when it was made with outdated parameters. Failures are only logged, as the
+// old hash keeps working.
+func (s *UserServiceImpl) rehashPassword(user *User, password string) {
+	if !s.PasswordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.PasswordHasher.Hash(password)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	user.Password = hash
+	err = s.UserRepository.Update(*user)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+func (s *UserServiceImpl) revokeAllTokens(userID uuid.UUID) (err error) {
+	_, err = s.TokenRevocationStore.BumpTokenVersion(userID)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	err = s.RefreshTokenRepository.RevokeByUserID(userID)
+	if err != nil {
+		return
+	}
+
+	return s.SessionRepository.RevokeByUserID(userID)
+}
+
+// checkStatus rejects suspended users, and unverified users when verification
+// is required.
+func (s *UserServiceImpl) checkStatus(user User) (err error) {
+	if user.IsSuspended() {
+		return failure.Forbidden("account is suspended")
+	}
+
+	if user.Status == StatusPending && s.Config.Auth.Verification.Required {
+		return failure.Forbidden("account is not verified")
+	}
+
+	return
+}
+
+// sendVerification issues a verification token for a user and delivers it
+// through the Notifier.
+func (s *UserServiceImpl) sendVerification(user User) (err error) {
+	verification, err := NewEmailVerification(user.Id, s.verificationExpiry())
+	if err != nil {
+		return
+	}
+
+	err = s.VerificationRepository.Create(verification)
 	if err != nil {
-		return "", err 
+		return
 	}
 
-	return tokenString, nil
-}
\ No newline at end of file
+	return s.Notifier.Notify(notifier.Message{
+		Recipient: user.Recipient(),
+		Subject:   "Verify your account",
+		Body:      "Open this link to verify your account: " + s.Config.App.URL + "/v1/users/verify?token=" + verification.Token,
+	})
+}
+
+// issueTokens starts a new session for a user signing in from client and
+// issues its access token and the first refresh token of its family.
+func (s *UserServiceImpl) issueTokens(user User, client ClientInfo) (login Login, err error) {
+	sessionID, err := uuid.NewV4()
+	if err != nil {
+		return Login{}, failure.InternalError(err)
+	}
+
+	login.User = user
+	login.Username = user.Username
+
+	var tokenID string
+	login.AccessToken, tokenID, err = s.generateAccessToken(user, sessionID.String())
+	if err != nil {
+		return Login{}, failure.InternalError(err)
+	}
+
+	err = s.SessionRepository.Create(NewSession(sessionID, user.Id, tokenID, client))
+	if err != nil {
+		return Login{}, err
+	}
+
+	login.RefreshToken, err = s.issueRefreshToken(user, sessionID)
+	if err != nil {
+		return Login{}, err
+	}
+
+	return
+}
+
+func (s *UserServiceImpl) issueRefreshToken(user User, familyID uuid.UUID) (string, error) {
+	token, err := NewRefreshToken(user.Id, familyID, s.refreshTokenExpiry())
+	if err != nil {
+		return "", failure.InternalError(err)
+	}
+
+	err = s.RefreshTokenRepository.Create(token)
+	if err != nil {
+		return "", err
+	}
+
+	return token.Token, nil
+}
+
+func (s *UserServiceImpl) accessTokenExpiry() time.Duration {
+	seconds := s.Config.Auth.JWT.AccessTokenExpirySeconds
+	if seconds <= 0 {
+		seconds = defaultAccessTokenExpirySeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (s *UserServiceImpl) refreshTokenExpiry() time.Duration {
+	seconds := s.Config.Auth.JWT.RefreshTokenExpirySeconds
+	if seconds <= 0 {
+		seconds = defaultRefreshTokenExpirySeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (s *UserServiceImpl) passwordResetExpiry() time.Duration {
+	seconds := s.Config.Auth.PasswordReset.TokenExpirySeconds
+	if seconds <= 0 {
+		seconds = defaultPasswordResetExpirySeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (s *UserServiceImpl) verificationExpiry() time.Duration {
+	seconds := s.Config.Auth.Verification.TokenExpirySeconds
+	if seconds <= 0 {
+		seconds = defaultVerificationExpirySeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
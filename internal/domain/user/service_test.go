@@ -0,0 +1,104 @@
+package user_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyClaimsStatus(t *testing.T) {
+	config := &configs.Config{}
+	config.Auth.Verification.Required = true
+	s := &user.UserServiceImpl{Config: config}
+
+	// suspended and unverified accounts get the same 403 as at login
+	for _, status := range []string{user.StatusSuspended, user.StatusPending} {
+		err := s.VerifyClaims(&jwtmodel.Claims{Status: status})
+		assert.Equal(t, http.StatusForbidden, failure.GetCode(err), status)
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	id, _ := uuid.NewV4()
+	config := &configs.Config{}
+	config.Auth.Verification.Required = true
+
+	cases := []struct {
+		name string
+		user user.User
+		code int
+	}{
+		{"active", user.User{Id: id, Status: user.StatusActive}, http.StatusOK},
+		{"suspended", user.User{Id: id, Status: user.StatusSuspended}, http.StatusForbidden},
+		{"unverified", user.User{Id: id, Status: user.StatusPending}, http.StatusForbidden},
+		{"deleted", user.User{Id: id, Status: user.StatusActive, DeletedAt: null.TimeFrom(time.Now()), DeletedBy: nuuid.From(id)}, http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		s := &user.UserServiceImpl{UserRepository: &userRepositoryStub{user: c.user}, Config: config}
+		err := s.VerifyUser(id)
+		if c.code == http.StatusOK {
+			assert.NoError(t, err, c.name)
+			continue
+		}
+		assert.Equal(t, c.code, failure.GetCode(err), c.name)
+	}
+}
+
+func TestUpdateUsernameTaken(t *testing.T) {
+	id, _ := uuid.NewV4()
+	repository := &userRepositoryStub{user: user.User{Id: id, Username: "alice", Status: user.StatusActive}, taken: true}
+	s := &user.UserServiceImpl{UserRepository: repository}
+
+	_, err := s.Update(id, user.UpdateUserRequestFormat{Username: "bobby", Name: "Alice"})
+	assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+}
+
+type twoFactorRepositoryStub struct {
+	user.TwoFactorRepository
+	challenge user.TwoFactorChallenge
+}
+
+func (r *twoFactorRepositoryStub) ResolveChallengeByTokenHash(tokenHash string) (user.TwoFactorChallenge, error) {
+	if tokenHash != r.challenge.TokenHash {
+		return user.TwoFactorChallenge{}, failure.NotFound("two-factor challenge")
+	}
+	return r.challenge, nil
+}
+
+func TestLoginTwoFactorChallenge(t *testing.T) {
+	id, _ := uuid.NewV4()
+	challenge, err := user.NewTwoFactorChallenge(id, 0, time.Minute)
+	assert.NoError(t, err)
+
+	used := challenge
+	used.UsedAt = null.TimeFrom(time.Now())
+
+	expired := challenge
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+
+	cases := []struct {
+		name      string
+		challenge user.TwoFactorChallenge
+		token     string
+	}{
+		{"unknown", challenge, "not-a-challenge"},
+		{"used", used, challenge.Token},
+		{"expired", expired, challenge.Token},
+	}
+
+	for _, c := range cases {
+		s := &user.UserServiceImpl{TwoFactorRepository: &twoFactorRepositoryStub{challenge: c.challenge}}
+		_, err := s.LoginTwoFactor(user.LoginTwoFactorRequestFormat{ChallengeToken: c.token, Code: "000000"})
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err), c.name)
+	}
+}
@@ -0,0 +1,87 @@
+package user
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+const maxUserAgentLength = 512
+
+// Session is a device a user is signed in on. A Session is created on every
+// successful login and shares its ID with the refresh token family issued
+// with it, so revoking a Session also revokes its refresh tokens. TokenID is
+// the jti of the most recent access token issued for the Session.
+type Session struct {
+	ID         uuid.UUID `db:"id"`
+	UserID     uuid.UUID `db:"userId"`
+	UserAgent  string    `db:"userAgent"`
+	IPAddress  string    `db:"ipAddress"`
+	TokenID    string    `db:"tokenId"`
+	CreatedAt  time.Time `db:"createdAt"`
+	LastSeenAt time.Time `db:"lastSeenAt"`
+	RevokedAt  null.Time `db:"revokedAt"`
+	Current    bool      `db:"-"`
+}
+
+// NewSession creates a new Session for a user signing in from a client.
+func NewSession(id uuid.UUID, userID uuid.UUID, tokenID string, client ClientInfo) Session {
+	userAgent := truncateRunes(client.UserAgent, maxUserAgentLength)
+
+	now := time.Now()
+	return Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  client.IPAddress,
+		TokenID:    tokenID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
+
+// truncateRunes cuts s to at most max characters, never splitting a
+// multi-byte character.
+func truncateRunes(s string, max int) string {
+	count := 0
+	for i := range s {
+		if count == max {
+			return s[:i]
+		}
+		count++
+	}
+
+	return s
+}
+
+func (s Session) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToResponseFormat())
+}
+
+func (s Session) ToResponseFormat() SessionResponseFormat {
+	return SessionResponseFormat{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		Current:    s.Current,
+	}
+}
+
+// ClientInfo describes the client a request came from.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type SessionResponseFormat struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
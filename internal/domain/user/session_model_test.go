@@ -0,0 +1,23 @@
+package user_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSessionTruncatesUserAgent(t *testing.T) {
+	id, _ := uuid.NewV4()
+
+	// 511 ASCII bytes followed by multi-byte characters straddling the limit
+	userAgent := strings.Repeat("a", 511) + strings.Repeat("é", 10)
+	session := user.NewSession(id, id, "token", user.ClientInfo{UserAgent: userAgent})
+
+	assert.True(t, utf8.ValidString(session.UserAgent))
+	assert.Equal(t, 512, utf8.RuneCountInString(session.UserAgent))
+	assert.True(t, strings.HasSuffix(session.UserAgent, "aé"))
+}
@@ -0,0 +1,177 @@
+package user
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var sessionQueries = struct {
+	selectSession  string
+	insertSession  string
+	touchSession   string
+	revokeSession  string
+	revokeByUserID string
+}{
+	selectSession: `
+		SELECT
+			id,
+			userId,
+			userAgent,
+			ipAddress,
+			tokenId,
+			createdAt,
+			lastSeenAt,
+			revokedAt
+		FROM user_sessions`,
+
+	insertSession: `
+		INSERT INTO user_sessions (
+			id,
+			userId,
+			userAgent,
+			ipAddress,
+			tokenId,
+			createdAt,
+			lastSeenAt,
+			revokedAt
+		) VALUES (
+			:id,
+			:userId,
+			:userAgent,
+			:ipAddress,
+			:tokenId,
+			:createdAt,
+			:lastSeenAt,
+			:revokedAt)`,
+
+	touchSession: `
+		UPDATE user_sessions
+		SET
+			tokenId = ?,
+			ipAddress = ?,
+			lastSeenAt = ?
+		WHERE id = ? AND revokedAt IS NULL`,
+
+	revokeSession: `
+		UPDATE user_sessions
+		SET revokedAt = ?
+		WHERE id = ? AND revokedAt IS NULL`,
+
+	revokeByUserID: `
+		UPDATE user_sessions
+		SET revokedAt = ?
+		WHERE userId = ? AND revokedAt IS NULL`,
+}
+
+// SessionRepository is the repository for Session data.
+type SessionRepository interface {
+	Create(session Session) (err error)
+	ResolveActiveByUserID(userID uuid.UUID, seenSince time.Time) (sessions []Session, err error)
+	ResolveByID(id uuid.UUID) (session Session, err error)
+	Revoke(id uuid.UUID) (err error)
+	RevokeByUserID(userID uuid.UUID) (err error)
+	Touch(id uuid.UUID, tokenID string, ipAddress string) (err error)
+}
+
+// SessionRepositoryMySQL is the MySQL-backed implementation of SessionRepository.
+type SessionRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideSessionRepositoryMySQL is the provider for this repository.
+func ProvideSessionRepositoryMySQL(db *infras.MySQLConn) *SessionRepositoryMySQL {
+	s := new(SessionRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// Create creates a new Session.
+func (r *SessionRepositoryMySQL) Create(session Session) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		stmt, err := tx.PrepareNamed(sessionQueries.insertSession)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(session); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// ResolveActiveByUserID resolves the Sessions of a user that are not revoked
+// and were last seen at or after seenSince, most recently seen first.
+func (r *SessionRepositoryMySQL) ResolveActiveByUserID(userID uuid.UUID, seenSince time.Time) (sessions []Session, err error) {
+	err = r.DB.Read.Select(
+		&sessions,
+		sessionQueries.selectSession+" WHERE userId = ? AND revokedAt IS NULL AND lastSeenAt >= ? ORDER BY lastSeenAt DESC",
+		userID.String(),
+		seenSince)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveByID resolves a Session by its ID.
+func (r *SessionRepositoryMySQL) ResolveByID(id uuid.UUID) (session Session, err error) {
+	err = r.DB.Read.Get(
+		&session,
+		sessionQueries.selectSession+" WHERE id = ?",
+		id.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("session")
+		return
+	}
+
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// Revoke revokes a Session. Revoking an already revoked Session is a no-op.
+func (r *SessionRepositoryMySQL) Revoke(id uuid.UUID) (err error) {
+	_, err = r.DB.Write.Exec(sessionQueries.revokeSession, time.Now(), id.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// RevokeByUserID revokes every active Session of a user.
+func (r *SessionRepositoryMySQL) RevokeByUserID(userID uuid.UUID) (err error) {
+	_, err = r.DB.Write.Exec(sessionQueries.revokeByUserID, time.Now(), userID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// Touch records that a Session was refreshed, storing the ID of its newest
+// access token and the client IP it was refreshed from.
+func (r *SessionRepositoryMySQL) Touch(id uuid.UUID, tokenID string, ipAddress string) (err error) {
+	_, err = r.DB.Write.Exec(sessionQueries.touchSession, tokenID, ipAddress, time.Now(), id.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
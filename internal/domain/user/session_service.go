@@ -0,0 +1,87 @@
+package user
+
+import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/gofrs/uuid"
+)
+
+// ResolveSessions resolves the active sessions of the user described by
+// claims, flagging the one the claims were issued for as current. A session
+// stays active until it is revoked or its refresh token expires.
+func (s *UserServiceImpl) ResolveSessions(claims *jwtmodel.Claims) (sessions []Session, err error) {
+	sessions, err = s.SessionRepository.ResolveActiveByUserID(claims.UserId, time.Now().Add(-s.refreshTokenExpiry()))
+	if err != nil {
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == claims.SessionID
+	}
+
+	return
+}
+
+// RevokeSession revokes a session of the user described by claims, signing
+// out the device it belongs to. Sessions of other users are reported as not
+// found.
+func (s *UserServiceImpl) RevokeSession(claims *jwtmodel.Claims, id uuid.UUID) (err error) {
+	session, err := s.SessionRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	if session.UserID != claims.UserId {
+		return failure.NotFound("session")
+	}
+
+	return s.revokeSession(session.ID)
+}
+
+// revokeSession revokes a session along with its refresh token family, and
+// marks it as revoked for as long as its access tokens can still be valid.
+func (s *UserServiceImpl) revokeSession(id uuid.UUID) (err error) {
+	err = s.SessionRepository.Revoke(id)
+	if err != nil {
+		return
+	}
+
+	err = s.RefreshTokenRepository.RevokeFamily(id)
+	if err != nil {
+		return
+	}
+
+	err = s.TokenRevocationStore.Revoke(sessionRevocationKey(id.String()), time.Now().Add(s.accessTokenExpiry()))
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	return
+}
+
+// verifySessionActive checks that the session a token was issued for has not
+// been revoked. Tokens issued without a session pass.
+func (s *UserServiceImpl) verifySessionActive(claims *jwtmodel.Claims) (err error) {
+	if claims.SessionID == "" {
+		return
+	}
+
+	revoked, err := s.TokenRevocationStore.IsRevoked(sessionRevocationKey(claims.SessionID))
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if revoked {
+		return failure.Unauthorized("session has been revoked")
+	}
+
+	return
+}
+
+// sessionRevocationKey is the key under which a revoked session is kept in
+// the TokenRevocationStore, apart from the jti of any single token.
+func sessionRevocationKey(sessionID string) string {
+	return "session:" + sessionID
+}
@@ -0,0 +1,157 @@
+package user
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-redis/redis"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	revokedTokenKeyFormat = "jwt:revoked:%s"
+	tokenVersionKeyFormat = "jwt:version:%s"
+)
+
+// TokenRevocationStore keeps track of access tokens that were revoked before
+// their expiry, and of a per-user token version used to revoke every token a
+// user holds at once.
+type TokenRevocationStore interface {
+	BumpTokenVersion(userID uuid.UUID) (version int64, err error)
+	IsRevoked(tokenID string) (revoked bool, err error)
+	Revoke(tokenID string, expiresAt time.Time) (err error)
+	TokenVersion(userID uuid.UUID) (version int64, err error)
+}
+
+// ProvideTokenRevocationStore is the provider for TokenRevocationStore. It
+// uses Redis when a client is available and falls back to an in-memory store,
+// which is only suitable for a single instance.
+func ProvideTokenRevocationStore(client *redis.Client) TokenRevocationStore {
+	if client == nil {
+		return NewTokenRevocationStoreInMemory()
+	}
+
+	return NewTokenRevocationStoreRedis(client)
+}
+
+// TokenRevocationStoreRedis is the Redis-backed implementation of TokenRevocationStore.
+type TokenRevocationStoreRedis struct {
+	Client *redis.Client
+}
+
+// NewTokenRevocationStoreRedis creates a new TokenRevocationStoreRedis.
+func NewTokenRevocationStoreRedis(client *redis.Client) *TokenRevocationStoreRedis {
+	return &TokenRevocationStoreRedis{Client: client}
+}
+
+// BumpTokenVersion increments the token version of a user, invalidating all
+// tokens issued with an older version.
+func (s *TokenRevocationStoreRedis) BumpTokenVersion(userID uuid.UUID) (version int64, err error) {
+	version, err = s.Client.Incr(fmt.Sprintf(tokenVersionKeyFormat, userID.String())).Result()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// IsRevoked checks whether a token ID has been revoked.
+func (s *TokenRevocationStoreRedis) IsRevoked(tokenID string) (revoked bool, err error) {
+	count, err := s.Client.Exists(fmt.Sprintf(revokedTokenKeyFormat, tokenID)).Result()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return count > 0, nil
+}
+
+// Revoke marks a token ID as revoked until the token would have expired anyway.
+func (s *TokenRevocationStoreRedis) Revoke(tokenID string, expiresAt time.Time) (err error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	err = s.Client.Set(fmt.Sprintf(revokedTokenKeyFormat, tokenID), 1, ttl).Err()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// TokenVersion resolves the current token version of a user.
+func (s *TokenRevocationStoreRedis) TokenVersion(userID uuid.UUID) (version int64, err error) {
+	version, err = s.Client.Get(fmt.Sprintf(tokenVersionKeyFormat, userID.String())).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// TokenRevocationStoreInMemory is an in-memory implementation of
+// TokenRevocationStore, intended for tests and local development.
+type TokenRevocationStoreInMemory struct {
+	mu       sync.Mutex
+	revoked  map[string]time.Time
+	versions map[uuid.UUID]int64
+}
+
+// NewTokenRevocationStoreInMemory creates a new TokenRevocationStoreInMemory.
+func NewTokenRevocationStoreInMemory() *TokenRevocationStoreInMemory {
+	return &TokenRevocationStoreInMemory{
+		revoked:  make(map[string]time.Time),
+		versions: make(map[uuid.UUID]int64),
+	}
+}
+
+// BumpTokenVersion increments the token version of a user.
+func (s *TokenRevocationStoreInMemory) BumpTokenVersion(userID uuid.UUID) (version int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions[userID]++
+	return s.versions[userID], nil
+}
+
+// IsRevoked checks whether a token ID has been revoked.
+func (s *TokenRevocationStoreInMemory) IsRevoked(tokenID string) (revoked bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.revoked[tokenID]
+	if !ok {
+		return false, nil
+	}
+
+	if time.Now().After(expiresAt) {
+		delete(s.revoked, tokenID)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Revoke marks a token ID as revoked until expiresAt.
+func (s *TokenRevocationStoreInMemory) Revoke(tokenID string, expiresAt time.Time) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revoked[tokenID] = expiresAt
+	return
+}
+
+// TokenVersion resolves the current token version of a user.
+func (s *TokenRevocationStoreInMemory) TokenVersion(userID uuid.UUID) (version int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.versions[userID], nil
+}
@@ -0,0 +1,72 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRevocationStoreInMemory(t *testing.T) {
+	t.Run("revoke", func(t *testing.T) {
+		store := user.NewTokenRevocationStoreInMemory()
+
+		revoked, err := store.IsRevoked("active")
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+
+		assert.NoError(t, store.Revoke("active", time.Now().Add(time.Minute)))
+		revoked, err = store.IsRevoked("active")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		assert.NoError(t, store.Revoke("expired", time.Now().Add(-time.Minute)))
+		revoked, err = store.IsRevoked("expired")
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("tokenVersion", func(t *testing.T) {
+		store := user.NewTokenRevocationStoreInMemory()
+		userID, _ := uuid.NewV4()
+
+		version, err := store.TokenVersion(userID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), version)
+
+		version, err = store.BumpTokenVersion(userID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), version)
+	})
+}
+
+func TestUserServiceVerifyClaims(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	newClaims := func(tokenID string, version int64) *jwtmodel.Claims {
+		return &jwtmodel.Claims{
+			UserId:       userID,
+			TokenVersion: version,
+			StandardClaims: jwt.StandardClaims{
+				Id:        tokenID,
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			},
+		}
+	}
+
+	store := user.NewTokenRevocationStoreInMemory()
+	s := &user.UserServiceImpl{TokenRevocationStore: store}
+
+	assert.NoError(t, s.VerifyClaims(newClaims("first", 0)))
+
+	assert.NoError(t, store.Revoke("first", time.Now().Add(time.Hour)))
+	assert.Error(t, s.VerifyClaims(newClaims("first", 0)))
+
+	_, err := store.BumpTokenVersion(userID)
+	assert.NoError(t, err)
+	assert.Error(t, s.VerifyClaims(newClaims("second", 0)))
+	assert.NoError(t, s.VerifyClaims(newClaims("third", 1)))
+}
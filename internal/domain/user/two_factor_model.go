@@ -0,0 +1,158 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+const (
+	recoveryCodeCount   = 10
+	recoveryCodeBytes   = 5
+	challengeTokenBytes = 32
+)
+
+// TwoFactor is the TOTP enrollment of a user. Secret holds the TOTP secret
+// encrypted at rest; the enrollment only takes effect once EnabledAt is set
+// after the user has proven they can generate codes. LastUsedStep is the time
+// step of the last accepted code, so a code cannot be replayed.
+type TwoFactor struct {
+	UserID       uuid.UUID `db:"userId"`
+	Secret       string    `db:"secret"`
+	EnabledAt    null.Time `db:"enabledAt"`
+	LastUsedStep int64     `db:"lastUsedStep"`
+	CreatedAt    time.Time `db:"createdAt"`
+	UpdatedAt    null.Time `db:"updatedAt"`
+}
+
+// IsEnabled checks whether this TwoFactor has been verified and is enforced at login.
+func (t *TwoFactor) IsEnabled() bool {
+	return t.EnabledAt.Valid
+}
+
+// RecoveryCode is a single-use code that completes a two-factor login when
+// the authenticator is unavailable. Only the SHA-256 hash of the code is
+// persisted.
+type RecoveryCode struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"userId"`
+	CodeHash  string    `db:"codeHash"`
+	CreatedAt time.Time `db:"createdAt"`
+	UsedAt    null.Time `db:"usedAt"`
+}
+
+// NewRecoveryCodes creates a fresh set of recovery codes for a user and
+// returns them along with their plaintext values.
+func NewRecoveryCodes(userID uuid.UUID) (codes []RecoveryCode, plain []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	now := time.Now()
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err = rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(raw))
+		codes = append(codes, RecoveryCode{
+			ID:        id,
+			UserID:    userID,
+			CodeHash:  HashRecoveryCode(code),
+			CreatedAt: now,
+		})
+		plain = append(plain, code)
+	}
+
+	return
+}
+
+// HashRecoveryCode hashes a recovery code for storage and lookup. Codes are
+// case-insensitive and may be entered with dashes or spaces.
+func HashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	return HashRefreshToken(normalized)
+}
+
+// TwoFactorChallenge is a single-use, time-limited token handed out by a
+// password login when two-factor authentication is enabled. It only allows
+// completing that login, and only the SHA-256 hash of the token is persisted.
+// TokenVersion is the token version of the user at issue, so revoking every
+// token of the user also revokes a pending challenge.
+type TwoFactorChallenge struct {
+	ID           uuid.UUID `db:"id"`
+	UserID       uuid.UUID `db:"userId"`
+	TokenHash    string    `db:"tokenHash"`
+	TokenVersion int64     `db:"tokenVersion"`
+	ExpiresAt    time.Time `db:"expiresAt"`
+	CreatedAt    time.Time `db:"createdAt"`
+	UsedAt       null.Time `db:"usedAt"`
+	Token        string    `db:"-"`
+}
+
+// NewTwoFactorChallenge creates a new TwoFactorChallenge for a user along with
+// its plaintext token.
+func NewTwoFactorChallenge(userID uuid.UUID, tokenVersion int64, ttl time.Duration) (challenge TwoFactorChallenge, err error) {
+	raw := make([]byte, challengeTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now()
+	challenge = TwoFactorChallenge{
+		ID:           id,
+		UserID:       userID,
+		TokenHash:    HashRefreshToken(plain),
+		TokenVersion: tokenVersion,
+		ExpiresAt:    now.Add(ttl),
+		CreatedAt:    now,
+		Token:        plain,
+	}
+
+	return
+}
+
+// IsUsable checks whether this TwoFactorChallenge is unused and not yet expired.
+func (c *TwoFactorChallenge) IsUsable() bool {
+	return !c.UsedAt.Valid && time.Now().Before(c.ExpiresAt)
+}
+
+type TwoFactorEnrollResponseFormat struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type TwoFactorCodeRequestFormat struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequestFormat struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponseFormat struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type LoginTwoFactorRequestFormat struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	ClientIP       string `json:"-"`
+	UserAgent      string `json:"-"`
+}
@@ -0,0 +1,401 @@
+package user
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var twoFactorQueries = struct {
+	selectTwoFactor     string
+	upsertTwoFactor     string
+	enableTwoFactor     string
+	useStep             string
+	deleteTwoFactor     string
+	insertRecoveryCode  string
+	deleteRecoveryCodes string
+	useRecoveryCode     string
+	selectChallenge     string
+	insertChallenge     string
+	useChallenge        string
+}{
+	selectTwoFactor: `
+		SELECT
+			userId,
+			secret,
+			enabledAt,
+			lastUsedStep,
+			createdAt,
+			updatedAt
+		FROM user_two_factors`,
+
+	upsertTwoFactor: `
+		INSERT INTO user_two_factors (
+			userId,
+			secret,
+			enabledAt,
+			lastUsedStep,
+			createdAt,
+			updatedAt
+		) VALUES (
+			:userId,
+			:secret,
+			:enabledAt,
+			:lastUsedStep,
+			:createdAt,
+			:updatedAt)
+		ON DUPLICATE KEY UPDATE
+			secret = VALUES(secret),
+			enabledAt = VALUES(enabledAt),
+			lastUsedStep = VALUES(lastUsedStep),
+			updatedAt = VALUES(updatedAt)`,
+
+	enableTwoFactor: `
+		UPDATE user_two_factors
+		SET
+			enabledAt = ?,
+			lastUsedStep = ?,
+			updatedAt = ?
+		WHERE userId = ? AND enabledAt IS NULL`,
+
+	useStep: `
+		UPDATE user_two_factors
+		SET
+			lastUsedStep = ?,
+			updatedAt = ?
+		WHERE userId = ? AND lastUsedStep < ?`,
+
+	deleteTwoFactor: `
+		DELETE FROM user_two_factors
+		WHERE userId = ?`,
+
+	insertRecoveryCode: `
+		INSERT INTO user_recovery_codes (
+			id,
+			userId,
+			codeHash,
+			createdAt,
+			usedAt
+		) VALUES (
+			:id,
+			:userId,
+			:codeHash,
+			:createdAt,
+			:usedAt)`,
+
+	deleteRecoveryCodes: `
+		DELETE FROM user_recovery_codes
+		WHERE userId = ?`,
+
+	useRecoveryCode: `
+		UPDATE user_recovery_codes
+		SET usedAt = ?
+		WHERE userId = ? AND codeHash = ? AND usedAt IS NULL`,
+
+	selectChallenge: `
+		SELECT
+			id,
+			userId,
+			tokenHash,
+			tokenVersion,
+			expiresAt,
+			createdAt,
+			usedAt
+		FROM user_two_factor_challenges`,
+
+	insertChallenge: `
+		INSERT INTO user_two_factor_challenges (
+			id,
+			userId,
+			tokenHash,
+			tokenVersion,
+			expiresAt,
+			createdAt,
+			usedAt
+		) VALUES (
+			:id,
+			:userId,
+			:tokenHash,
+			:tokenVersion,
+			:expiresAt,
+			:createdAt,
+			:usedAt)`,
+
+	useChallenge: `
+		UPDATE user_two_factor_challenges
+		SET usedAt = ?
+		WHERE id = ? AND usedAt IS NULL`,
+}
+
+// TwoFactorRepository is the repository for TwoFactor, RecoveryCode and
+// TwoFactorChallenge data.
+type TwoFactorRepository interface {
+	CreateChallenge(challenge TwoFactorChallenge) (err error)
+	Delete(userID uuid.UUID) (err error)
+	Enable(userID uuid.UUID, step int64, codes []RecoveryCode) (err error)
+	ReplaceRecoveryCodes(userID uuid.UUID, codes []RecoveryCode) (err error)
+	ResolveByUserID(userID uuid.UUID) (twoFactor TwoFactor, err error)
+	ResolveChallengeByTokenHash(tokenHash string) (challenge TwoFactorChallenge, err error)
+	Save(twoFactor TwoFactor) (err error)
+	UseChallenge(id uuid.UUID) (err error)
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (err error)
+	UseStep(userID uuid.UUID, step int64) (err error)
+}
+
+// TwoFactorRepositoryMySQL is the MySQL-backed implementation of TwoFactorRepository.
+type TwoFactorRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideTwoFactorRepositoryMySQL is the provider for this repository.
+func ProvideTwoFactorRepositoryMySQL(db *infras.MySQLConn) *TwoFactorRepositoryMySQL {
+	s := new(TwoFactorRepositoryMySQL)
+	s.DB = db
+	return s
+}
+
+// CreateChallenge creates a new TwoFactorChallenge.
+func (r *TwoFactorRepositoryMySQL) CreateChallenge(challenge TwoFactorChallenge) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		stmt, err := tx.PrepareNamed(twoFactorQueries.insertChallenge)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(challenge); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// Delete removes the TwoFactor and every RecoveryCode of a user.
+func (r *TwoFactorRepositoryMySQL) Delete(userID uuid.UUID) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if _, err := tx.Exec(twoFactorQueries.deleteRecoveryCodes, userID.String()); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		if _, err := tx.Exec(twoFactorQueries.deleteTwoFactor, userID.String()); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// Enable enables a pending TwoFactor, recording the step of the code that
+// proved the enrollment, and stores its first set of recovery codes. It fails
+// with a conflict if the TwoFactor is already enabled.
+func (r *TwoFactorRepositoryMySQL) Enable(userID uuid.UUID, step int64, codes []RecoveryCode) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		now := time.Now()
+		result, err := tx.Exec(twoFactorQueries.enableTwoFactor, now, step, now, userID.String())
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			e <- err
+			return
+		}
+
+		if affected == 0 {
+			e <- failure.Conflict("enable", "two-factor authentication", "already enabled")
+			return
+		}
+
+		if err := r.txReplaceRecoveryCodes(tx, userID, codes); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// ReplaceRecoveryCodes replaces every RecoveryCode of a user with codes.
+func (r *TwoFactorRepositoryMySQL) ReplaceRecoveryCodes(userID uuid.UUID, codes []RecoveryCode) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := r.txReplaceRecoveryCodes(tx, userID, codes); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// ResolveByUserID resolves the TwoFactor of a user.
+func (r *TwoFactorRepositoryMySQL) ResolveByUserID(userID uuid.UUID) (twoFactor TwoFactor, err error) {
+	err = r.DB.Read.Get(
+		&twoFactor,
+		twoFactorQueries.selectTwoFactor+" WHERE userId = ?",
+		userID.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("two-factor authentication")
+		return
+	}
+
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveChallengeByTokenHash resolves a TwoFactorChallenge by the hash of its
+// plaintext token. It reads from the primary, as a challenge is redeemed right
+// after it is issued.
+func (r *TwoFactorRepositoryMySQL) ResolveChallengeByTokenHash(tokenHash string) (challenge TwoFactorChallenge, err error) {
+	err = r.DB.Write.Get(
+		&challenge,
+		twoFactorQueries.selectChallenge+" WHERE tokenHash = ?",
+		tokenHash)
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("two-factor challenge")
+		return
+	}
+
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// Save creates or replaces the TwoFactor of a user.
+func (r *TwoFactorRepositoryMySQL) Save(twoFactor TwoFactor) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		stmt, err := tx.PrepareNamed(twoFactorQueries.upsertTwoFactor)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(twoFactor); err != nil {
+			logger.ErrorWithStack(err)
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// UseChallenge marks a TwoFactorChallenge as used. It fails with a conflict if
+// the challenge has already been used, so a challenge can only be redeemed
+// once even under concurrent requests.
+func (r *TwoFactorRepositoryMySQL) UseChallenge(id uuid.UUID) (err error) {
+	result, err := r.DB.Write.Exec(twoFactorQueries.useChallenge, time.Now(), id.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.Conflict("use", "two-factor challenge", "already used")
+	}
+
+	return
+}
+
+// UseRecoveryCode marks an unused RecoveryCode of a user as used. It fails
+// with not found if there is no such unused code.
+func (r *TwoFactorRepositoryMySQL) UseRecoveryCode(userID uuid.UUID, codeHash string) (err error) {
+	result, err := r.DB.Write.Exec(twoFactorQueries.useRecoveryCode, time.Now(), userID.String(), codeHash)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.NotFound("recovery code")
+	}
+
+	return
+}
+
+// UseStep records step as the last used time step of a user's TwoFactor. It
+// fails with a conflict if a code from the same or a later step was already
+// used, which rejects replayed codes.
+func (r *TwoFactorRepositoryMySQL) UseStep(userID uuid.UUID, step int64) (err error) {
+	result, err := r.DB.Write.Exec(twoFactorQueries.useStep, step, time.Now(), userID.String(), step)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.Conflict("use", "two-factor code", "already used")
+	}
+
+	return
+}
+
+// internal methods
+
+// txReplaceRecoveryCodes replaces the recovery codes of a user transactionally
+// given the *sqlx.Tx param.
+func (r *TwoFactorRepositoryMySQL) txReplaceRecoveryCodes(tx *sqlx.Tx, userID uuid.UUID, codes []RecoveryCode) (err error) {
+	_, err = tx.Exec(twoFactorQueries.deleteRecoveryCodes, userID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	stmt, err := tx.PrepareNamed(twoFactorQueries.insertRecoveryCode)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	for _, code := range codes {
+		_, err = stmt.Exec(code)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+
+	return
+}
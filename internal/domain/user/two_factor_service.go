@@ -0,0 +1,376 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/encryption"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/totp"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultTwoFactorIssuer                 = "Evermos"
+	defaultTwoFactorChallengeExpirySeconds = 5 * 60
+	twoFactorSkew                          = 1
+)
+
+// ErrTwoFactorNotConfigured is returned when two-factor authentication is used
+// without an encryption key for its secrets.
+var ErrTwoFactorNotConfigured = errors.New("two-factor authentication is not configured")
+
+// ProvideTwoFactorCipher is the provider for the cipher that encrypts TOTP
+// secrets at rest. It returns nil when no key is configured, which disables
+// enrolling in two-factor authentication.
+func ProvideTwoFactorCipher(config *configs.Config) *encryption.AESGCM {
+	key := config.Auth.TwoFactor.EncryptionKey
+	if key == "" {
+		return nil
+	}
+
+	cipher, err := encryption.NewAESGCMFromBase64(key)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed loading two-factor encryption key")
+	}
+
+	return cipher
+}
+
+// EnrollTwoFactor starts a TOTP enrollment for the user described by claims.
+// The returned secret only takes effect once EnableTwoFactor confirms a code.
+func (s *UserServiceImpl) EnrollTwoFactor(claims *jwtmodel.Claims) (enrollment TwoFactorEnrollResponseFormat, err error) {
+	if s.TwoFactorCipher == nil {
+		return enrollment, failure.InternalError(ErrTwoFactorNotConfigured)
+	}
+
+	enabled, err := s.isTwoFactorEnabled(claims.UserId)
+	if err != nil {
+		return
+	}
+
+	if enabled {
+		return enrollment, failure.Conflict("enroll", "two-factor authentication", "already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return enrollment, failure.InternalError(err)
+	}
+
+	encrypted, err := s.TwoFactorCipher.Encrypt([]byte(secret))
+	if err != nil {
+		return enrollment, failure.InternalError(err)
+	}
+
+	now := time.Now()
+	err = s.TwoFactorRepository.Save(TwoFactor{
+		UserID:    claims.UserId,
+		Secret:    encrypted,
+		CreatedAt: now,
+		UpdatedAt: null.TimeFrom(now),
+	})
+	if err != nil {
+		return
+	}
+
+	enrollment = TwoFactorEnrollResponseFormat{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.twoFactorIssuer(), claims.Username, secret),
+	}
+
+	return
+}
+
+// EnableTwoFactor confirms a pending enrollment with a code from the
+// authenticator and returns the user's recovery codes, which are only shown
+// once.
+func (s *UserServiceImpl) EnableTwoFactor(claims *jwtmodel.Claims, requestFormat TwoFactorCodeRequestFormat) (recoveryCodes RecoveryCodesResponseFormat, err error) {
+	twoFactor, err := s.TwoFactorRepository.ResolveByUserID(claims.UserId)
+	if err != nil {
+		return
+	}
+
+	if twoFactor.IsEnabled() {
+		return recoveryCodes, failure.Conflict("enable", "two-factor authentication", "already enabled")
+	}
+
+	step, err := s.validateTOTP(twoFactor, requestFormat.Code)
+	if err != nil {
+		return
+	}
+
+	codes, plain, err := NewRecoveryCodes(claims.UserId)
+	if err != nil {
+		return recoveryCodes, failure.InternalError(err)
+	}
+
+	err = s.TwoFactorRepository.Enable(claims.UserId, step, codes)
+	if err != nil {
+		return
+	}
+
+	recoveryCodes.RecoveryCodes = plain
+	return
+}
+
+// DisableTwoFactor removes the two-factor enrollment of the user described by
+// claims after checking their password and a current code.
+func (s *UserServiceImpl) DisableTwoFactor(claims *jwtmodel.Claims, requestFormat TwoFactorDisableRequestFormat) (err error) {
+	user, err := s.UserRepository.ResolveByID(claims.UserId)
+	if err != nil {
+		return
+	}
+
+	match, err := s.PasswordHasher.Verify(requestFormat.Password, user.Password)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	if !match {
+		return failure.BadRequestFromString("Password False!")
+	}
+
+	twoFactor, err := s.TwoFactorRepository.ResolveByUserID(claims.UserId)
+	if err != nil {
+		return
+	}
+
+	if twoFactor.IsEnabled() {
+		err = s.verifyTwoFactorCode(twoFactor, requestFormat.Code)
+		if err != nil {
+			return
+		}
+	}
+
+	return s.TwoFactorRepository.Delete(claims.UserId)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user described by
+// claims after checking a current code.
+func (s *UserServiceImpl) RegenerateRecoveryCodes(claims *jwtmodel.Claims, requestFormat TwoFactorCodeRequestFormat) (recoveryCodes RecoveryCodesResponseFormat, err error) {
+	twoFactor, err := s.TwoFactorRepository.ResolveByUserID(claims.UserId)
+	if err != nil {
+		return
+	}
+
+	if !twoFactor.IsEnabled() {
+		return recoveryCodes, failure.NotFound("two-factor authentication")
+	}
+
+	step, err := s.validateTOTP(twoFactor, requestFormat.Code)
+	if err != nil {
+		return
+	}
+
+	err = s.useStep(twoFactor, step)
+	if err != nil {
+		return
+	}
+
+	codes, plain, err := NewRecoveryCodes(claims.UserId)
+	if err != nil {
+		return recoveryCodes, failure.InternalError(err)
+	}
+
+	err = s.TwoFactorRepository.ReplaceRecoveryCodes(claims.UserId, codes)
+	if err != nil {
+		return
+	}
+
+	recoveryCodes.RecoveryCodes = plain
+	return
+}
+
+// LoginTwoFactor completes a login that was answered with a challenge token,
+// given a TOTP code or a recovery code. Failed codes count towards the login
+// lockout like failed passwords, and the challenge is used up once a code is
+// accepted.
+func (s *UserServiceImpl) LoginTwoFactor(requestFormat LoginTwoFactorRequestFormat) (login Login, err error) {
+	challenge, err := s.TwoFactorRepository.ResolveChallengeByTokenHash(HashRefreshToken(requestFormat.ChallengeToken))
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.Unauthorized("invalid challenge token")
+		}
+		return
+	}
+
+	if !challenge.IsUsable() {
+		return login, failure.Unauthorized("invalid challenge token")
+	}
+
+	version, err := s.TokenRevocationStore.TokenVersion(challenge.UserID)
+	if err != nil {
+		return login, failure.InternalError(err)
+	}
+
+	if challenge.TokenVersion < version {
+		return login, failure.Unauthorized("invalid challenge token")
+	}
+
+	user, err := s.UserRepository.ResolveByID(challenge.UserID)
+	if err != nil {
+		return
+	}
+
+	if user.IsDeleted() {
+		return login, failure.Unauthorized("invalid challenge token")
+	}
+
+	err = s.LoginLimiter.Check(user.Username, requestFormat.ClientIP)
+	if err != nil {
+		return
+	}
+
+	err = s.checkStatus(user)
+	if err != nil {
+		return
+	}
+
+	twoFactor, err := s.TwoFactorRepository.ResolveByUserID(user.Id)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.Unauthorized("invalid challenge token")
+		}
+		return
+	}
+
+	if !twoFactor.IsEnabled() {
+		return login, failure.Unauthorized("invalid challenge token")
+	}
+
+	err = s.verifyTwoFactorCode(twoFactor, requestFormat.Code)
+	if err != nil {
+		if limitErr := s.LoginLimiter.RegisterFailure(user.Username, requestFormat.ClientIP); limitErr != nil {
+			return login, limitErr
+		}
+		return
+	}
+
+	err = s.TwoFactorRepository.UseChallenge(challenge.ID)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusConflict {
+			err = failure.Unauthorized("invalid challenge token")
+		}
+		return
+	}
+
+	err = s.LoginLimiter.RegisterSuccess(user.Username)
+	if err != nil {
+		return
+	}
+
+	return s.issueTokens(user, ClientInfo{IPAddress: requestFormat.ClientIP, UserAgent: requestFormat.UserAgent})
+}
+
+// isTwoFactorEnabled checks whether a user has a confirmed two-factor enrollment.
+func (s *UserServiceImpl) isTwoFactorEnabled(userID uuid.UUID) (enabled bool, err error) {
+	twoFactor, err := s.TwoFactorRepository.ResolveByUserID(userID)
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = nil
+		}
+		return
+	}
+
+	return twoFactor.IsEnabled(), nil
+}
+
+// generateChallengeToken stores a short-lived, single-use challenge that can
+// only be used to complete a two-factor login and returns its opaque token.
+func (s *UserServiceImpl) generateChallengeToken(user User) (string, error) {
+	version, err := s.TokenRevocationStore.TokenVersion(user.Id)
+	if err != nil {
+		return "", err
+	}
+
+	seconds := s.Config.Auth.TwoFactor.ChallengeExpirySeconds
+	if seconds <= 0 {
+		seconds = defaultTwoFactorChallengeExpirySeconds
+	}
+
+	challenge, err := NewTwoFactorChallenge(user.Id, version, time.Duration(seconds)*time.Second)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.TwoFactorRepository.CreateChallenge(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	return challenge.Token, nil
+}
+
+// verifyTwoFactorCode accepts either a current TOTP code that has not been
+// used before or an unused recovery code.
+func (s *UserServiceImpl) verifyTwoFactorCode(twoFactor TwoFactor, code string) (err error) {
+	step, err := s.validateTOTP(twoFactor, code)
+	if err == nil {
+		return s.useStep(twoFactor, step)
+	}
+
+	if failure.GetCode(err) != http.StatusUnauthorized {
+		return
+	}
+
+	err = s.TwoFactorRepository.UseRecoveryCode(twoFactor.UserID, HashRecoveryCode(code))
+	if err != nil {
+		if failure.GetCode(err) == http.StatusNotFound {
+			err = failure.Unauthorized("invalid two-factor code")
+		}
+		return
+	}
+
+	return
+}
+
+// validateTOTP checks a TOTP code against the secret of twoFactor and returns
+// its time step.
+func (s *UserServiceImpl) validateTOTP(twoFactor TwoFactor, code string) (step int64, err error) {
+	if s.TwoFactorCipher == nil {
+		return 0, failure.InternalError(ErrTwoFactorNotConfigured)
+	}
+
+	secret, err := s.TwoFactorCipher.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return 0, failure.InternalError(err)
+	}
+
+	step, ok, err := totp.Validate(string(secret), code, time.Now(), twoFactorSkew)
+	if err != nil {
+		return 0, failure.InternalError(err)
+	}
+
+	if !ok {
+		return 0, failure.Unauthorized("invalid two-factor code")
+	}
+
+	return
+}
+
+// useStep records a TOTP time step as used, rejecting replayed codes.
+func (s *UserServiceImpl) useStep(twoFactor TwoFactor, step int64) (err error) {
+	if step <= twoFactor.LastUsedStep {
+		return failure.Unauthorized("invalid two-factor code")
+	}
+
+	err = s.TwoFactorRepository.UseStep(twoFactor.UserID, step)
+	if failure.GetCode(err) == http.StatusConflict {
+		err = failure.Unauthorized("invalid two-factor code")
+	}
+
+	return
+}
+
+func (s *UserServiceImpl) twoFactorIssuer() string {
+	if s.Config.Auth.TwoFactor.Issuer == "" {
+		return defaultTwoFactorIssuer
+	}
+	return s.Config.Auth.TwoFactor.Issuer
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/apikey"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+// AdminAPIKeyHandler is the HTTP handler for managing API keys as an admin.
+type AdminAPIKeyHandler struct {
+	APIKeyService     apikey.APIKeyService
+	JWTAuthMiddleware *middleware.JWTAuthentication
+	Authorization     *middleware.Authorization
+}
+
+// ProvideAdminAPIKeyHandler is the provider for this handler.
+func ProvideAdminAPIKeyHandler(apiKeyService apikey.APIKeyService, jwtAuthMiddleware *middleware.JWTAuthentication, authorization *middleware.Authorization) AdminAPIKeyHandler {
+	return AdminAPIKeyHandler{
+		APIKeyService:     apiKeyService,
+		JWTAuthMiddleware: jwtAuthMiddleware,
+		Authorization:     authorization,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *AdminAPIKeyHandler) Router(r chi.Router) {
+	r.Route("/admin/api-keys", func(r chi.Router) {
+		r.Use(h.JWTAuthMiddleware.JWTMiddlewareValidate)
+		r.Use(h.Authorization.RequireRole(user.RoleAdmin))
+		r.Get("/", h.ResolveAPIKeys)
+		r.Post("/", h.CreateAPIKey)
+		r.Get("/{id}", h.ResolveAPIKeyByID)
+		r.Post("/{id}/revoke", h.RevokeAPIKey)
+	})
+}
+
+// ResolveAPIKeys resolves every API key.
+// @Summary Resolve API keys
+// @Description This endpoint lists API keys, most recently created first. Keys are never returned, only their prefixes.
+// @Tags admin/api-keys
+// @Security EVMOauthToken
+// @Produce json
+// @Success 200 {object} response.Base{data=[]apikey.APIKeyResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/api-keys [get]
+func (h *AdminAPIKeyHandler) ResolveAPIKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := h.APIKeyService.ResolveAll()
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, apiKeys)
+}
+
+// CreateAPIKey issues a new API key.
+// @Summary Issue an API key
+// @Description This endpoint issues a named API key with the given scopes and returns it. The key is only stored as a hash and cannot be shown again.
+// @Tags admin/api-keys
+// @Security EVMOauthToken
+// @Param apiKey body apikey.APIKeyRequestFormat true "The API key to be issued."
+// @Produce json
+// @Success 201 {object} response.Base{data=apikey.APIKeyResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/api-keys [post]
+func (h *AdminAPIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	var requestFormat apikey.APIKeyRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	apiKey, err := h.APIKeyService.Create(requestFormat, claims.UserId)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, apiKey)
+}
+
+// ResolveAPIKeyByID resolves an API key by its ID.
+// @Summary Resolve an API key
+// @Description This endpoint resolves an API key by its ID, including when it was last used.
+// @Tags admin/api-keys
+// @Security EVMOauthToken
+// @Param id path string true "The API key's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=apikey.APIKeyResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/api-keys/{id} [get]
+func (h *AdminAPIKeyHandler) ResolveAPIKeyByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	apiKey, err := h.APIKeyService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, apiKey)
+}
+
+// RevokeAPIKey revokes an API key.
+// @Summary Revoke an API key
+// @Description This endpoint revokes an API key; requests carrying it are rejected from then on.
+// @Tags admin/api-keys
+// @Security EVMOauthToken
+// @Param id path string true "The API key's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=apikey.APIKeyResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/api-keys/{id}/revoke [post]
+func (h *AdminAPIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	apiKey, err := h.APIKeyService.Revoke(id, claims.UserId)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, apiKey)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/oauthclient"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+)
+
+// AdminOAuthClientHandler is the HTTP handler for managing OAuth clients as an admin.
+type AdminOAuthClientHandler struct {
+	ClientService     oauthclient.ClientService
+	TokenCache        oauth.TokenCache
+	JWTAuthMiddleware *middleware.JWTAuthentication
+	Authorization     *middleware.Authorization
+}
+
+// ProvideAdminOAuthClientHandler is the provider for this handler.
+func ProvideAdminOAuthClientHandler(clientService oauthclient.ClientService, tokenCache oauth.TokenCache, jwtAuthMiddleware *middleware.JWTAuthentication, authorization *middleware.Authorization) AdminOAuthClientHandler {
+	return AdminOAuthClientHandler{
+		ClientService:     clientService,
+		TokenCache:        tokenCache,
+		JWTAuthMiddleware: jwtAuthMiddleware,
+		Authorization:     authorization,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *AdminOAuthClientHandler) Router(r chi.Router) {
+	r.Route("/admin/oauth/clients", func(r chi.Router) {
+		r.Use(h.JWTAuthMiddleware.JWTMiddlewareValidate)
+		r.Use(h.Authorization.RequireRole(user.RoleAdmin))
+		r.Get("/", h.ResolveClients)
+		r.Post("/", h.CreateClient)
+		r.Get("/{clientId}", h.ResolveClientByClientID)
+		r.Post("/{clientId}/disable", h.DisableClient)
+		r.Post("/{clientId}/rotate-secret", h.RotateClientSecret)
+	})
+	r.Route("/admin/oauth/token-cache", func(r chi.Router) {
+		r.Use(h.JWTAuthMiddleware.JWTMiddlewareValidate)
+		r.Use(h.Authorization.RequireRole(user.RoleAdmin))
+		r.Get("/", h.ResolveTokenCacheStats)
+	})
+}
+
+// ResolveTokenCacheStats resolves the counters of the access token cache.
+// @Summary Resolve token cache counters
+// @Description This endpoint returns how many access token lookups this instance answered from its cache and how many fell through to the database since it started.
+// @Tags admin/oauth
+// @Security EVMOauthToken
+// @Produce json
+// @Success 200 {object} response.Base{data=oauth.TokenCacheStats}
+// @Failure 403 {object} response.Base
+// @Router /v1/admin/oauth/token-cache [get]
+func (h *AdminOAuthClientHandler) ResolveTokenCacheStats(w http.ResponseWriter, r *http.Request) {
+	response.WithJSON(w, http.StatusOK, h.TokenCache.Stats())
+}
+
+// ResolveClients resolves every OAuth client.
+// @Summary Resolve OAuth clients
+// @Description This endpoint lists OAuth clients, most recently created first. Secrets are never returned.
+// @Tags admin/oauth
+// @Security EVMOauthToken
+// @Produce json
+// @Success 200 {object} response.Base{data=[]oauthclient.ClientResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth/clients [get]
+func (h *AdminOAuthClientHandler) ResolveClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.ClientService.ResolveAll()
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, clients)
+}
+
+// CreateClient registers a new OAuth client.
+// @Summary Register an OAuth client
+// @Description This endpoint registers an OAuth client and returns its secret. The secret is only stored as a hash and cannot be shown again.
+// @Tags admin/oauth
+// @Security EVMOauthToken
+// @Param client body oauthclient.ClientRequestFormat true "The client to be registered."
+// @Produce json
+// @Success 201 {object} response.Base{data=oauthclient.ClientResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth/clients [post]
+func (h *AdminOAuthClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var requestFormat oauthclient.ClientRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	client, err := h.ClientService.Create(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, client)
+}
+
+// ResolveClientByClientID resolves an OAuth client by its client ID.
+// @Summary Resolve an OAuth client
+// @Description This endpoint resolves an OAuth client by its client ID.
+// @Tags admin/oauth
+// @Security EVMOauthToken
+// @Param clientId path string true "The client ID."
+// @Produce json
+// @Success 200 {object} response.Base{data=oauthclient.ClientResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth/clients/{clientId} [get]
+func (h *AdminOAuthClientHandler) ResolveClientByClientID(w http.ResponseWriter, r *http.Request) {
+	client, err := h.ClientService.ResolveByClientID(chi.URLParam(r, "clientId"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, client)
+}
+
+// DisableClient disables an OAuth client.
+// @Summary Disable an OAuth client
+// @Description This endpoint disables an OAuth client and deletes every token issued to it.
+// @Tags admin/oauth
+// @Security EVMOauthToken
+// @Param clientId path string true "The client ID."
+// @Produce json
+// @Success 200 {object} response.Base{data=oauthclient.ClientResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth/clients/{clientId}/disable [post]
+func (h *AdminOAuthClientHandler) DisableClient(w http.ResponseWriter, r *http.Request) {
+	client, err := h.ClientService.Disable(chi.URLParam(r, "clientId"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, client)
+}
+
+// RotateClientSecret generates a new secret for an OAuth client.
+// @Summary Rotate an OAuth client's secret
+// @Description This endpoint generates a new secret for an OAuth client and returns it. The current secret keeps working for the overlap window.
+// @Tags admin/oauth
+// @Security EVMOauthToken
+// @Param clientId path string true "The client ID."
+// @Param rotation body oauthclient.RotateSecretRequestFormat false "The overlap window, defaults to the configured one."
+// @Produce json
+// @Success 200 {object} response.Base{data=oauthclient.ClientResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth/clients/{clientId}/rotate-secret [post]
+func (h *AdminOAuthClientHandler) RotateClientSecret(w http.ResponseWriter, r *http.Request) {
+	var requestFormat oauthclient.RotateSecretRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil && err != io.EOF {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	client, err := h.ClientService.RotateSecret(chi.URLParam(r, "clientId"), requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, client)
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// AdminUserHandler is the HTTP handler for managing users as an admin.
+type AdminUserHandler struct {
+	UserService       user.UserService
+	JWTAuthMiddleware *middleware.JWTAuthentication
+	Authorization     *middleware.Authorization
+}
+
+// ProvideAdminUserHandler is the provider for this handler.
+func ProvideAdminUserHandler(userService user.UserService, jwtAuthMiddleware *middleware.JWTAuthentication, authorization *middleware.Authorization) AdminUserHandler {
+	return AdminUserHandler{
+		UserService:       userService,
+		JWTAuthMiddleware: jwtAuthMiddleware,
+		Authorization:     authorization,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *AdminUserHandler) Router(r chi.Router) {
+	r.Route("/admin/users", func(r chi.Router) {
+		r.Use(h.JWTAuthMiddleware.JWTMiddlewareValidate)
+		r.Use(h.Authorization.RequireRole(user.RoleAdmin))
+		r.Get("/", h.ResolveUsers)
+		r.Get("/{id}", h.ResolveUserByID)
+		r.Delete("/{id}", h.SoftDeleteUser)
+		r.Post("/{id}/restore", h.RestoreUser)
+		r.Put("/{id}/role", h.ChangeUserRole)
+		r.Put("/{id}/status", h.ChangeUserStatus)
+		r.Post("/{id}/unlock", h.UnlockUser)
+	})
+}
+
+// ResolveUsers resolves a page of users.
+// @Summary Resolve users
+// @Description This endpoint lists users, most recently created first.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param role query string false "Only users with this role."
+// @Param username query string false "Only users whose username starts with this prefix."
+// @Param createdFrom query string false "Only users created at or after this RFC 3339 time."
+// @Param createdTo query string false "Only users created before this RFC 3339 time."
+// @Param includeDeleted query string false "Include deleted users, default false."
+// @Param page query int false "Page number, default 1."
+// @Param pageSize query int false "Page size, default 20, max 100."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserPage}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users [get]
+func (h *AdminUserHandler) ResolveUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := user.UserFilter{
+		Role:           query.Get("role"),
+		UsernamePrefix: query.Get("username"),
+	}
+
+	var err error
+	filter.CreatedFrom, err = parseTimeQuery(query.Get("createdFrom"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	filter.CreatedTo, err = parseTimeQuery(query.Get("createdTo"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	filter.IncludeDeleted, _ = strconv.ParseBool(query.Get("includeDeleted"))
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(query.Get("pageSize"))
+
+	page, err := h.UserService.ResolveAll(filter)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, page)
+}
+
+// ResolveUserByID resolves a user by its ID.
+// @Summary Resolve user by ID
+// @Description This endpoint resolves a user by its ID, including deleted users.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param id path string true "The user's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users/{id} [get]
+func (h *AdminUserHandler) ResolveUserByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+// SoftDeleteUser marks a user as deleted.
+// @Summary Mark a user as deleted
+// @Description This endpoint marks a user as deleted and revokes all of its tokens.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param id path string true "The user's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users/{id} [delete]
+func (h *AdminUserHandler) SoftDeleteUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.SoftDelete(id, claims.UserId)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+// RestoreUser clears the deleted mark of a user.
+// @Summary Restore a deleted user
+// @Description This endpoint restores a user that was marked as deleted.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param id path string true "The user's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users/{id}/restore [post]
+func (h *AdminUserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.Restore(id, claims.UserId)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+// ChangeUserRole changes the role of a user.
+// @Summary Change a user's role
+// @Description This endpoint changes a user's role and revokes its existing tokens.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param id path string true "The user's identifier."
+// @Param role body user.ChangeRoleRequestFormat true "The new role."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users/{id}/role [put]
+func (h *AdminUserHandler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	var requestFormat user.ChangeRoleRequestFormat
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.ChangeRole(id, requestFormat, claims.UserId)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+// ChangeUserStatus changes the status of a user.
+// @Summary Change a user's status
+// @Description This endpoint activates or suspends a user and revokes its existing tokens.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param id path string true "The user's identifier."
+// @Param status body user.ChangeStatusRequestFormat true "The new status."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users/{id}/status [put]
+func (h *AdminUserHandler) ChangeUserStatus(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	var requestFormat user.ChangeStatusRequestFormat
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.ChangeStatus(id, requestFormat, claims.UserId)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+// UnlockUser clears the login lockout of a user.
+// @Summary Unlock a user
+// @Description This endpoint clears the failed logins and lockout of a user.
+// @Tags admin/users
+// @Security EVMOauthToken
+// @Param id path string true "The user's identifier."
+// @Produce json
+// @Success 204 "No Content"
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/users/{id}/unlock [post]
+func (h *AdminUserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.Unlock(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter.
+func parseTimeQuery(value string) (null.Time, error) {
+	if value == "" {
+		return null.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return null.Time{}, err
+	}
+
+	return null.TimeFrom(t), nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
 	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/go-chi/chi"
@@ -18,26 +19,36 @@ import (
 type FooBarBazHandler struct {
 	FooService     foobarbaz.FooService
 	AuthMiddleware *middleware.Authentication
+	Authenticator  *middleware.AuthenticatorChain
+	Authorization  *middleware.Authorization
 }
 
 // ProvideFooBarBazHandler is the provider for this handler.
-func ProvideFooBarBazHandler(fooService foobarbaz.FooService, authMiddleware *middleware.Authentication) FooBarBazHandler {
+func ProvideFooBarBazHandler(fooService foobarbaz.FooService, authMiddleware *middleware.Authentication, authenticator *middleware.AuthenticatorChain, authorization *middleware.Authorization) FooBarBazHandler {
 	return FooBarBazHandler{
 		FooService:     fooService,
 		AuthMiddleware: authMiddleware,
+		Authenticator:  authenticator,
+		Authorization:  authorization,
 	}
 }
 
 // Router sets up the router for this domain.
 func (h *FooBarBazHandler) Router(r chi.Router) {
 	r.Route("/foobarbaz", func(r chi.Router) {
+		r.Use(h.Authenticator.Authenticate)
+
 		r.Group(func(r chi.Router) {
-			r.Use(h.AuthMiddleware.ClientCredential)
+			r.Use(h.AuthMiddleware.RequireScopes("foo:read"))
+			r.Use(h.Authorization.RequirePermission("foo:read"))
+			r.Get("/foo", h.ResolveFoos)
 			r.Get("/foo/{id}", h.ResolveFooByID)
 		})
 
 		r.Group(func(r chi.Router) {
-			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireUserToken)
+			r.Use(h.AuthMiddleware.RequireScopes("foo:write"))
+			r.Use(h.Authorization.RequirePermission("foo:write"))
 			r.Post("/foo", h.CreateFoo)
 			r.Delete("/foo/{id}", h.SoftDeleteFoo)
 			r.Put("/foo/{id}", h.UpdateFoo)
@@ -55,6 +66,8 @@ func (h *FooBarBazHandler) Router(r chi.Router) {
 // @Produce json
 // @Success 201 {object} response.Base{data=foobarbaz.FooResponseFormat}
 // @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
 // @Failure 409 {object} response.Base
 // @Failure 500 {object} response.Base
 // @Router /v1/foobarbaz/foo [post]
@@ -73,7 +86,11 @@ func (h *FooBarBazHandler) CreateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	userID, ok := middleware.ActorIDFromContext(r.Context())
+	if !ok {
+		response.WithError(w, failure.Forbidden("writes require credentials that identify a user"))
+		return
+	}
 
 	foo, err := h.FooService.Create(requestFormat, userID)
 	if err != nil {
@@ -84,6 +101,75 @@ func (h *FooBarBazHandler) CreateFoo(w http.ResponseWriter, r *http.Request) {
 	response.WithJSON(w, http.StatusCreated, foo)
 }
 
+// ResolveFoos resolves a page of Foos.
+// @Summary Resolve Foos
+// @Description This endpoint lists Foos that are not deleted. Pass the nextCursor of a page's
+// @Description pagination as cursor, with the same filters and sort, to fetch the next page.
+// @Tags foobarbaz/foo
+// @Security EVMOauthToken
+// @Param status query string false "Only Foos with this status."
+// @Param createdFrom query string false "Only Foos created at or after this RFC 3339 time."
+// @Param createdTo query string false "Only Foos created before this RFC 3339 time."
+// @Param createdBy query string false "Only Foos created by this user."
+// @Param name query string false "Only Foos whose name contains this text."
+// @Param sort query string false "One of created, -created, name or -name, default -created."
+// @Param cursor query string false "The nextCursor of the previous page."
+// @Param pageSize query int false "Page size, default 20, max 100."
+// @Param withItems query string false "Fetch with items, default false."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]foobarbaz.FooResponseFormat,pagination=response.Pagination}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/foobarbaz/foo [get]
+func (h *FooBarBazHandler) ResolveFoos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := foobarbaz.FooFilter{
+		Status: foobarbaz.FooStatus(query.Get("status")),
+		Name:   query.Get("name"),
+		Sort:   foobarbaz.FooSort(query.Get("sort")),
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	filter.CreatedFrom, err = parseTimeQuery(query.Get("createdFrom"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	filter.CreatedTo, err = parseTimeQuery(query.Get("createdTo"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	if createdBy := query.Get("createdBy"); createdBy != "" {
+		id, err := uuid.FromString(createdBy)
+		if err != nil {
+			response.WithError(w, failure.BadRequest(err))
+			return
+		}
+		filter.CreatedBy = nuuid.From(id)
+	}
+
+	filter.PageSize, _ = strconv.Atoi(query.Get("pageSize"))
+	filter.WithItems, _ = strconv.ParseBool(query.Get("withItems"))
+
+	page, err := h.FooService.ResolveAll(filter)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithPaginatedJSON(w, http.StatusOK, page.Foos, response.Pagination{
+		NextCursor: page.NextCursor,
+		HasMore:    page.NextCursor != "",
+		PageSize:   page.PageSize,
+	})
+}
+
 // ResolveFooByID resolves a Foo by its ID.
 // @Summary Resolve Foo by ID
 // @Description This endpoint resolves a Foo by its ID.
@@ -94,6 +180,8 @@ func (h *FooBarBazHandler) CreateFoo(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Success 200 {object} response.Base{data=foobarbaz.FooResponseFormat}
 // @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
 // @Failure 404 {object} response.Base
 // @Failure 500 {object} response.Base
 // @Router /v1/foobarbaz/foo/{id} [get]
@@ -126,6 +214,8 @@ func (h *FooBarBazHandler) ResolveFooByID(w http.ResponseWriter, r *http.Request
 // @Produce json
 // @Success 200 {object} response.Base{data=foobarbaz.FooResponseFormat}
 // @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
 // @Failure 409 {object} response.Base
 // @Failure 500 {object} response.Base
 // @Router /v1/foobarbaz/foo/{id} [delete]
@@ -137,7 +227,11 @@ func (h *FooBarBazHandler) SoftDeleteFoo(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	userID, ok := middleware.ActorIDFromContext(r.Context())
+	if !ok {
+		response.WithError(w, failure.Forbidden("writes require credentials that identify a user"))
+		return
+	}
 
 	foo, err := h.FooService.SoftDelete(id, userID)
 	if err != nil {
@@ -158,6 +252,8 @@ func (h *FooBarBazHandler) SoftDeleteFoo(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Success 200 {object} response.Base{data=foobarbaz.FooResponseFormat}
 // @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
 // @Failure 409 {object} response.Base
 // @Failure 500 {object} response.Base
 // @Router /v1/foobarbaz/foo/{id} [put]
@@ -183,7 +279,11 @@ func (h *FooBarBazHandler) UpdateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	userID, ok := middleware.ActorIDFromContext(r.Context())
+	if !ok {
+		response.WithError(w, failure.Forbidden("writes require credentials that identify a user"))
+		return
+	}
 
 	foo, err := h.FooService.Update(id, requestFormat, userID)
 	if err != nil {
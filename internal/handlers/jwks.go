@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/go-chi/chi"
+)
+
+// JWKSHandler is the HTTP handler that publishes the public keys used to sign
+// access tokens, so that other services can validate them without a secret.
+type JWKSHandler struct {
+	KeySet *jwtmodel.KeySet
+}
+
+// ProvideJWKSHandler is the provider for this handler.
+func ProvideJWKSHandler(keySet *jwtmodel.KeySet) JWKSHandler {
+	return JWKSHandler{
+		KeySet: keySet,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *JWKSHandler) Router(r chi.Router) {
+	r.Get("/.well-known/jwks.json", h.ResolveJWKS)
+}
+
+// ResolveJWKS resolves the public JSON Web Key Set.
+// @Summary Resolve JSON Web Key Set
+// @Description This endpoint returns the public keys used to sign access tokens.
+// @Tags well-known
+// @Produce json
+// @Success 200 {object} jwtmodel.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) ResolveJWKS(w http.ResponseWriter, r *http.Request) {
+	payload, _ := json.Marshal(h.KeySet.JWKS())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(payload)
+}
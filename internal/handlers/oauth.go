@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/go-chi/chi"
+)
+
+// OAuthHandler is the HTTP handler for the OAuth2 endpoints defined in
+// RFC 6749. Its responses are plain JSON objects as the RFC requires, not
+// wrapped in response.Base.
+type OAuthHandler struct {
+	Token             *oauth.Token
+	JWTAuthMiddleware *middleware.JWTAuthentication
+}
+
+// ProvideOAuthHandler is the provider for this handler.
+func ProvideOAuthHandler(token *oauth.Token, jwtAuthMiddleware *middleware.JWTAuthentication) OAuthHandler {
+	return OAuthHandler{
+		Token:             token,
+		JWTAuthMiddleware: jwtAuthMiddleware,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *OAuthHandler) Router(r chi.Router) {
+	r.Route("/oauth", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(h.JWTAuthMiddleware.JWTMiddlewareValidate)
+			r.Get("/authorize", h.ResolveAuthorization)
+			r.Post("/authorize", h.Authorize)
+		})
+		r.Post("/token", h.CreateToken)
+		r.Post("/introspect", h.IntrospectToken)
+		r.Post("/revoke", h.RevokeToken)
+	})
+}
+
+// ResolveAuthorization validates an authorization request for the consent page.
+// @Summary Validate an OAuth2 authorization request
+// @Description This endpoint validates an authorization_code request of a third-party client and describes what the signed-in user is asked to consent to.
+// @Description Errors carry a redirect_uri once the client and redirect_uri are known to be valid; the consent page sends the user agent there instead of showing the error, as RFC 6749 section 4.1.2.1 describes.
+// @Tags oauth
+// @Security EVMOauthToken
+// @Param response_type query string true "Must be code."
+// @Param client_id query string true "The client's identifier."
+// @Param redirect_uri query string true "One of the client's registered redirect URIs."
+// @Param scope query string false "The space-delimited scope to request, defaults to every scope the client is allowed."
+// @Param state query string false "An opaque value returned to the client unchanged."
+// @Param code_challenge query string true "The PKCE code challenge."
+// @Param code_challenge_method query string true "Must be S256."
+// @Produce json
+// @Success 200 {object} oauth.Authorization
+// @Failure 400 {object} oauth.AuthorizationErrorResponse
+// @Failure 401 {object} response.Base
+// @Router /oauth/authorize [get]
+func (h *OAuthHandler) ResolveAuthorization(w http.ResponseWriter, r *http.Request) {
+	authorization, err := h.Token.VerifyAuthorizationRequest(authorizationRequest(r))
+	if err != nil {
+		h.writeAuthorizationError(w, authorization, err)
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, authorization)
+}
+
+// Authorize grants or denies an authorization request.
+// @Summary Grant an OAuth2 authorization code
+// @Description This endpoint records the signed-in user's consent to an authorization_code request and returns the redirect_uri the user agent must be sent to, carrying either a single-use code or an error.
+// @Tags oauth
+// @Security EVMOauthToken
+// @Accept x-www-form-urlencoded
+// @Param response_type formData string true "Must be code."
+// @Param client_id formData string true "The client's identifier."
+// @Param redirect_uri formData string true "One of the client's registered redirect URIs."
+// @Param scope formData string false "The space-delimited scope to request, defaults to every scope the client is allowed."
+// @Param state formData string false "An opaque value returned to the client unchanged."
+// @Param code_challenge formData string true "The PKCE code challenge."
+// @Param code_challenge_method formData string true "Must be S256."
+// @Param consent formData string true "allow to grant the request, anything else denies it."
+// @Produce json
+// @Success 200 {object} oauth.AuthorizationRedirect
+// @Failure 400 {object} oauth.AuthorizationErrorResponse
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} oauth.AuthorizationErrorResponse
+// @Failure 500 {object} oauth.ErrorResponse
+// @Router /oauth/authorize [post]
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		writeOAuthJSON(w, http.StatusBadRequest, oauth.ErrorResponse{
+			Error:            oauth.ErrorCodeInvalidRequest,
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+
+	request := authorizationRequest(r)
+	if r.PostForm.Get("consent") != "allow" {
+		authorization, err := h.Token.VerifyAuthorizationRequest(request)
+		if err == nil {
+			err = errors.New(oauth.ErrorAccessDenied)
+		}
+		h.writeAuthorizationError(w, authorization, err)
+		return
+	}
+
+	authorization, err := h.Token.Authorize(request, claims.UserId.String())
+	if err != nil {
+		h.writeAuthorizationError(w, authorization, err)
+		return
+	}
+
+	redirectURI, err := authorization.CodeRedirectURI()
+	if err != nil {
+		writeOAuthError(w, err, false)
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, oauth.AuthorizationRedirect{RedirectURI: redirectURI})
+}
+
+// writeAuthorizationError reports an invalid or denied authorization request.
+// Once the redirect URI of authorization is trusted the response also
+// carries it, with the error added, for the consent page to send the user
+// agent back to the client.
+func (h *OAuthHandler) writeAuthorizationError(w http.ResponseWriter, authorization oauth.Authorization, err error) {
+	errorResponse, code := oauth.NewErrorResponse(err)
+	if code == http.StatusInternalServerError {
+		logger.ErrorWithStack(err)
+	}
+
+	response := oauth.AuthorizationErrorResponse{ErrorResponse: errorResponse}
+	if authorization.RedirectURI != "" {
+		response.RedirectURI, _ = authorization.ErrorRedirectURI(err)
+	}
+
+	writeOAuthJSON(w, code, response)
+}
+
+// CreateToken issues an access token.
+// @Summary Issue an OAuth2 access token
+// @Description This endpoint issues an access token for the client_credentials, password, refresh_token and authorization_code grants.
+// @Description Clients authenticate with HTTP Basic or with client_id and client_secret in the body.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param grant_type formData string true "The grant type."
+// @Param client_id formData string false "The client's identifier."
+// @Param client_secret formData string false "The client's secret."
+// @Param username formData string false "The resource owner's username, for the password grant."
+// @Param password formData string false "The resource owner's password, for the password grant."
+// @Param refresh_token formData string false "The refresh token, for the refresh_token grant."
+// @Param code formData string false "The authorization code, for the authorization_code grant."
+// @Param redirect_uri formData string false "The redirect URI the code was granted for, for the authorization_code grant."
+// @Param code_verifier formData string false "The PKCE code verifier, for the authorization_code grant."
+// @Param scope formData string false "The space-delimited scope to request, defaults to every scope the client is allowed."
+// @Produce json
+// @Success 200 {object} oauth.TokenResponse
+// @Failure 400 {object} oauth.ErrorResponse
+// @Failure 401 {object} oauth.ErrorResponse
+// @Failure 500 {object} oauth.ErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeOAuthJSON(w, http.StatusBadRequest, oauth.ErrorResponse{
+			Error:            oauth.ErrorCodeInvalidRequest,
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+
+	credential, basicAuth := clientCredential(r)
+	credential.GrantType = oauth.GrantType(r.PostForm.Get("grant_type"))
+	credential.Username = r.PostForm.Get("username")
+	credential.Password = r.PostForm.Get("password")
+	credential.ClientIP = clientIP(r)
+	credential.RefreshToken = r.PostForm.Get("refresh_token")
+	credential.Scope = r.PostForm.Get("scope")
+	credential.Code = r.PostForm.Get("code")
+	credential.RedirectURI = r.PostForm.Get("redirect_uri")
+	credential.CodeVerifier = r.PostForm.Get("code_verifier")
+
+	token, err := h.Token.Create(credential)
+	if err != nil {
+		writeOAuthError(w, err, basicAuth)
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, token)
+}
+
+// IntrospectToken describes a token.
+// @Summary Introspect an OAuth2 token
+// @Description This endpoint reports whether an access or refresh token is active, along with its scope, client and expiry, as described in RFC 7662.
+// @Description Callers authenticate with their own client credentials.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "The token to introspect."
+// @Param token_type_hint formData string false "access_token or refresh_token."
+// @Param client_id formData string false "The client's identifier."
+// @Param client_secret formData string false "The client's secret."
+// @Produce json
+// @Success 200 {object} oauth.IntrospectionResponse
+// @Failure 400 {object} oauth.ErrorResponse
+// @Failure 401 {object} oauth.ErrorResponse
+// @Failure 500 {object} oauth.ErrorResponse
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeOAuthJSON(w, http.StatusBadRequest, oauth.ErrorResponse{
+			Error:            oauth.ErrorCodeInvalidRequest,
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+
+	credential, basicAuth := clientCredential(r)
+	introspection, err := h.Token.Introspect(credential, r.PostForm.Get("token"), r.PostForm.Get("token_type_hint"))
+	if err != nil {
+		writeOAuthError(w, err, basicAuth)
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, introspection)
+}
+
+// RevokeToken revokes a token.
+// @Summary Revoke an OAuth2 token
+// @Description This endpoint revokes an access or refresh token of the calling client, as described in RFC 7009.
+// @Description Unknown tokens are ignored, so the response does not reveal whether a token existed.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "The token to revoke."
+// @Param token_type_hint formData string false "access_token or refresh_token."
+// @Param client_id formData string false "The client's identifier."
+// @Param client_secret formData string false "The client's secret."
+// @Success 200 "OK"
+// @Failure 400 {object} oauth.ErrorResponse
+// @Failure 401 {object} oauth.ErrorResponse
+// @Failure 500 {object} oauth.ErrorResponse
+// @Router /oauth/revoke [post]
+func (h *OAuthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeOAuthJSON(w, http.StatusBadRequest, oauth.ErrorResponse{
+			Error:            oauth.ErrorCodeInvalidRequest,
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+
+	credential, basicAuth := clientCredential(r)
+	err = h.Token.Revoke(credential, r.PostForm.Get("token"), r.PostForm.Get("token_type_hint"))
+	if err != nil {
+		writeOAuthError(w, err, basicAuth)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorizationRequest reads the authorization request of r from its query
+// or form body.
+func authorizationRequest(r *http.Request) oauth.AuthorizationRequest {
+	return oauth.AuthorizationRequest{
+		ResponseType:        r.FormValue("response_type"),
+		ClientID:            r.FormValue("client_id"),
+		RedirectURI:         r.FormValue("redirect_uri"),
+		Scope:               r.FormValue("scope"),
+		State:               r.FormValue("state"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+	}
+}
+
+// clientCredential reads the client credentials of r from HTTP Basic
+// authentication, falling back to the form body. It reports whether Basic
+// authentication was used.
+func clientCredential(r *http.Request) (credential oauth.Credential, basicAuth bool) {
+	credential.ClientID, credential.ClientSecret, basicAuth = r.BasicAuth()
+	if !basicAuth {
+		credential.ClientID = r.PostForm.Get("client_id")
+		credential.ClientSecret = r.PostForm.Get("client_secret")
+	}
+
+	return
+}
+
+// writeOAuthError writes the RFC 6749 error response for err.
+func writeOAuthError(w http.ResponseWriter, err error, basicAuth bool) {
+	errorResponse, code := oauth.NewErrorResponse(err)
+	if code == http.StatusInternalServerError {
+		logger.ErrorWithStack(err)
+	}
+	if code == http.StatusUnauthorized && basicAuth {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	writeOAuthJSON(w, code, errorResponse)
+}
+
+// writeOAuthJSON writes an OAuth2 response, which must never be cached.
+func writeOAuthJSON(w http.ResponseWriter, code int, payload interface{}) {
+	body, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(code)
+	_, err := w.Write(body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
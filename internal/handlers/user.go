@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 
 	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
-	"github.com/evermos/boilerplate-go/shared/jwtmodel"
 	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
 )
 
 type UserHandler struct {
@@ -31,6 +34,12 @@ func (h *UserHandler) Router(r chi.Router)  {
 		r.Group(func(r chi.Router) {
 			r.Post("/", h.CreateUser)
 			r.Post("/login", h.Login)
+			r.Post("/login/2fa", h.LoginTwoFactor)
+			r.Post("/token/refresh", h.RefreshToken)
+			r.Post("/password/forgot", h.ForgotPassword)
+			r.Post("/password/reset", h.ResetPassword)
+			r.Get("/verify", h.VerifyEmail)
+			r.Post("/verify/resend", h.ResendVerification)
 		})
 
 		r.Group(func(r chi.Router) {
@@ -38,6 +47,15 @@ func (h *UserHandler) Router(r chi.Router)  {
 			r.Get("/validate", h.Validate)
 			r.Get("/profile", h.Profile)
 			r.Put("/profile", h.UpdateUser)
+			r.Put("/password", h.ChangePassword)
+			r.Post("/2fa/enroll", h.EnrollTwoFactor)
+			r.Post("/2fa/enable", h.EnableTwoFactor)
+			r.Post("/2fa/disable", h.DisableTwoFactor)
+			r.Post("/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+			r.Post("/logout", h.Logout)
+			r.Post("/logout/all", h.LogoutAll)
+			r.Get("/sessions", h.ResolveSessions)
+			r.Delete("/sessions/{id}", h.RevokeSession)
 			// r.Delete("/foo/{id}", h.SoftDeleteFoo)
 		})
 
@@ -74,7 +92,8 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-
+	requestFormat.ClientIP = clientIP(r)
+	requestFormat.UserAgent = r.UserAgent()
 
 	login, err := h.UserService.Login(requestFormat)
 	if err != nil {
@@ -85,15 +104,116 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	response.WithJSON(w, http.StatusOK, login)
 }
 
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat user.RefreshTokenRequestFormat
+	err := decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	requestFormat.ClientIP = clientIP(r)
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	login, err := h.UserService.RefreshToken(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, login)
+}
+
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	var requestFormat user.LogoutRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil && err != io.EOF {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.Logout(claims, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *UserHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.UserService.LogoutAll(claims)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *UserHandler) ResolveSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.UserService.ResolveSessions(claims)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, sessions)
+}
+
+func (h *UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.RevokeSession(claims, id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
 
 func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
-	claims, ok := r.Context().Value(middleware.ClaimsKey("claims")).(*jwtmodel.Claims)
+	claims, ok := middleware.ClaimsFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error Claims", http.StatusUnauthorized)
 		return
 	}
 
-	user, err := h.UserService.ResolveByUsername(claims.Username)
+	user, err := h.UserService.ResolveProfile(claims.UserId)
 	if err != nil {
 		response.WithError(w, err)
 		return
@@ -105,7 +225,7 @@ func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
 
 func (h *UserHandler) Validate(w http.ResponseWriter, r *http.Request) {
 	
-	claims, ok := r.Context().Value(middleware.ClaimsKey("claims")).(*jwtmodel.Claims)
+	claims, ok := middleware.ClaimsFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error Claims", http.StatusUnauthorized)
 		return
@@ -118,26 +238,278 @@ func (h *UserHandler) Validate(w http.ResponseWriter, r *http.Request) {
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
-	claims, ok := r.Context().Value(middleware.ClaimsKey("claims")).(*jwtmodel.Claims)
+	claims, ok := middleware.ClaimsFromContext(r.Context())
 	if !ok {
 		http.Error(w, "Error Claims", http.StatusUnauthorized)
 		return
 	}
 
 	decoder := json.NewDecoder(r.Body)
-	var requestFormat user.UserRequestFormat
+	var requestFormat user.UpdateUserRequestFormat
 	err := decoder.Decode(&requestFormat)
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
 
-	user, err := h.UserService.Update(claims.Username, requestFormat)
+	user, err := h.UserService.Update(claims.UserId, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	var requestFormat user.ChangePasswordRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	requestFormat.ClientIP = clientIP(r)
+	requestFormat.UserAgent = r.UserAgent()
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	login, err := h.UserService.ChangePassword(claims, requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	response.WithJSON(w, http.StatusOK, login)
+}
+
+func (h *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var requestFormat user.ForgotPasswordRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.ForgotPassword(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var requestFormat user.ResetPasswordRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.ResetPassword(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *UserHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var requestFormat user.LoginTwoFactorRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	requestFormat.ClientIP = clientIP(r)
+	requestFormat.UserAgent = r.UserAgent()
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	login, err := h.UserService.LoginTwoFactor(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, login)
+}
+
+func (h *UserHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.UserService.EnrollTwoFactor(claims)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *UserHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	var requestFormat user.TwoFactorCodeRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	recoveryCodes, err := h.UserService.EnableTwoFactor(claims, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, recoveryCodes)
+}
+
+func (h *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	var requestFormat user.TwoFactorDisableRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.DisableTwoFactor(claims, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Error Claims", http.StatusUnauthorized)
+		return
+	}
+
+	var requestFormat user.TwoFactorCodeRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	recoveryCodes, err := h.UserService.RegenerateRecoveryCodes(claims, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, recoveryCodes)
+}
+
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		response.WithError(w, failure.BadRequestFromString("token is required"))
+		return
+	}
+
+	user, err := h.UserService.VerifyEmail(token)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
 	response.WithJSON(w, http.StatusOK, user)
-}
\ No newline at end of file
+}
+
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var requestFormat user.ResendVerificationRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.UserService.ResendVerification(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// clientIP returns the IP address of the client that sent r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
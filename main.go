@@ -6,6 +6,7 @@ package main
 import (
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/rs/zerolog/log"
 )
 
 var config *configs.Config
@@ -24,7 +25,10 @@ func main() {
 	logger.SetLogLevel(config)
 
 	// Wire everything up
-	http := InitializeService()
+	http, err := InitializeService()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed wiring the service")
+	}
 
 	// consumers := InitializeEvent()
 
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `user_refresh_tokens` (
+  `id` CHAR(36) NOT NULL,
+  `familyId` CHAR(36) NOT NULL,
+  `userId` CHAR(36) NOT NULL,
+  `tokenHash` CHAR(64) NOT NULL,
+  `expiresAt` TIMESTAMP NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `revokedAt` TIMESTAMP NULL DEFAULT NULL,
+  `replacedBy` CHAR(36) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_user_refresh_tokens_1` (`tokenHash`),
+  INDEX `idx_user_refresh_tokens_2` (`familyId`),
+  INDEX `idx_user_refresh_tokens_3` (`userId`),
+  INDEX `idx_user_refresh_tokens_4` (`expiresAt`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
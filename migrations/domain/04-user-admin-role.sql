@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+  MODIFY `role` ENUM('teacher', 'student', 'admin') DEFAULT NULL,
+  ADD INDEX `idx_users_1` (`role`),
+  ADD INDEX `idx_users_2` (`createdAt`),
+  ADD INDEX `idx_users_3` (`deletedAt`);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `user_password_resets` (
+  `id` CHAR(36) NOT NULL,
+  `userId` CHAR(36) NOT NULL,
+  `tokenHash` CHAR(64) NOT NULL,
+  `expiresAt` TIMESTAMP NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `usedAt` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_user_password_resets_1` (`tokenHash`),
+  INDEX `idx_user_password_resets_2` (`userId`),
+  INDEX `idx_user_password_resets_3` (`expiresAt`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `user_two_factors` (
+  `userId` CHAR(36) NOT NULL,
+  `secret` VARCHAR(255) NOT NULL,
+  `enabledAt` TIMESTAMP NULL DEFAULT NULL,
+  `lastUsedStep` BIGINT NOT NULL DEFAULT 0,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`userId`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `user_recovery_codes` (
+  `id` CHAR(36) NOT NULL,
+  `userId` CHAR(36) NOT NULL,
+  `codeHash` CHAR(64) NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `usedAt` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_user_recovery_codes_1` (`userId`, `codeHash`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
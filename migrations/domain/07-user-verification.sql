@@ -0,0 +1,20 @@
+ALTER TABLE `users`
+  ADD `email` VARCHAR(255) NULL DEFAULT NULL AFTER `name`,
+  ADD `status` ENUM('pending', 'active', 'suspended') NOT NULL DEFAULT 'active' AFTER `role`,
+  ADD `emailVerifiedAt` TIMESTAMP NULL DEFAULT NULL AFTER `status`,
+  ADD UNIQUE `idx_users_4` (`email`),
+  ADD INDEX `idx_users_5` (`status`);
+
+CREATE TABLE IF NOT EXISTS `user_verifications` (
+  `id` CHAR(36) NOT NULL,
+  `userId` CHAR(36) NOT NULL,
+  `tokenHash` CHAR(64) NOT NULL,
+  `expiresAt` TIMESTAMP NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `usedAt` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_user_verifications_1` (`tokenHash`),
+  INDEX `idx_user_verifications_2` (`userId`),
+  INDEX `idx_user_verifications_3` (`expiresAt`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+  `id` CHAR(36) NOT NULL,
+  `userId` CHAR(36) NOT NULL,
+  `userAgent` VARCHAR(512) NOT NULL DEFAULT '',
+  `ipAddress` VARCHAR(45) NOT NULL DEFAULT '',
+  `tokenId` CHAR(36) NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `lastSeenAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `revokedAt` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_sessions_1` (`userId`, `revokedAt`, `lastSeenAt`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,6 @@
+RENAME TABLE `oauth_access_token` TO `oauth_access_tokens`;
+
+INSERT IGNORE INTO `oauth_clients`
+(`client_id`, `client_secret`, `redirect_uri`, `grant_types`, `scope`, `user_id`)
+VALUES
+('client_web', '3v3rm0s', 'https://evermos.com/', 'client_credentials password refresh_token', 'user', NULL);
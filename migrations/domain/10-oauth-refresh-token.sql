@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `oauth_refresh_tokens` (
+    `refresh_token` VARCHAR(40) NOT NULL,
+    `client_id` VARCHAR(32) NOT NULL,
+    `user_id` VARCHAR(20) NULL,
+    `expires` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `scope` VARCHAR(2000) NULL,
+    PRIMARY KEY (`refresh_token`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
@@ -0,0 +1,3 @@
+UPDATE `oauth_clients`
+SET `scope` = 'user foo:read'
+WHERE `client_id` = 'client_web' AND `scope` = 'user';
@@ -0,0 +1,10 @@
+ALTER TABLE `oauth_clients`
+    MODIFY `client_secret` VARCHAR(64) NOT NULL,
+    ADD COLUMN `previous_client_secret` VARCHAR(64) NULL AFTER `client_secret`,
+    ADD COLUMN `previous_secret_expires` TIMESTAMP NULL DEFAULT NULL AFTER `previous_client_secret`,
+    ADD COLUMN `disabled_at` TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN `updated_at` TIMESTAMP NULL DEFAULT NULL;
+
+-- client secrets are stored as SHA-256 hashes from now on
+UPDATE `oauth_clients` SET `client_secret` = SHA2(`client_secret`, 256);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `oauth_authorization_codes` (
+    `code` VARCHAR(40) NOT NULL,
+    `client_id` VARCHAR(32) NOT NULL,
+    `user_id` VARCHAR(36) NOT NULL,
+    `redirect_uri` VARCHAR(1000) NOT NULL,
+    `scope` VARCHAR(2000) NULL,
+    `code_challenge` VARCHAR(128) NOT NULL,
+    `code_challenge_method` VARCHAR(10) NOT NULL,
+    `expires` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`code`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
+
+-- codes are granted by users signed in with a JWT, whose IDs are UUIDs
+ALTER TABLE `oauth_access_tokens` MODIFY `user_id` VARCHAR(36) NULL;
+ALTER TABLE `oauth_refresh_tokens` MODIFY `user_id` VARCHAR(36) NULL;
@@ -0,0 +1,4 @@
+-- lets the token janitor find expired rows without scanning the tables
+CREATE INDEX `idx_oauth_access_tokens_expires` ON `oauth_access_tokens` (`expires`);
+CREATE INDEX `idx_oauth_refresh_tokens_expires` ON `oauth_refresh_tokens` (`expires`);
+CREATE INDEX `idx_oauth_authorization_codes_expires` ON `oauth_authorization_codes` (`expires`);
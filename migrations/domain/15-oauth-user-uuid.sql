@@ -0,0 +1,5 @@
+-- users are identified by UUID; tokens issued to the old integer IDs of the
+-- never-existing `user` table cannot belong to anyone
+ALTER TABLE `oauth_clients` MODIFY `user_id` VARCHAR(36) NULL;
+DELETE FROM `oauth_access_tokens` WHERE `user_id` IS NOT NULL AND CHAR_LENGTH(`user_id`) <> 36;
+DELETE FROM `oauth_refresh_tokens` WHERE `user_id` IS NOT NULL AND CHAR_LENGTH(`user_id`) <> 36;
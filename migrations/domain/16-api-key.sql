@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+  `id` CHAR(36) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `prefix` VARCHAR(16) NOT NULL,
+  `key_hash` VARCHAR(64) NOT NULL,
+  `scope` VARCHAR(2000) NOT NULL,
+  `expires_at` TIMESTAMP NULL DEFAULT NULL,
+  `last_used_at` TIMESTAMP NULL DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `created_by` CHAR(36) NOT NULL,
+  `revoked_at` TIMESTAMP NULL DEFAULT NULL,
+  `revoked_by` CHAR(36) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_api_keys_1` (`prefix`),
+  INDEX `idx_api_keys_2` (`created_at`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- the client seeded by 02-oauth.sql and 09-oauth-token.sql shipped with a public
+-- secret; it is only removed while that secret is unchanged, so a rotated
+-- client_web is kept
+DELETE t FROM `oauth_access_tokens` t
+JOIN `oauth_clients` c ON c.`client_id` = t.`client_id`
+WHERE c.`client_id` = 'client_web' AND c.`client_secret` IN ('3v3rm0s', SHA2('3v3rm0s', 256));
+
+DELETE t FROM `oauth_refresh_tokens` t
+JOIN `oauth_clients` c ON c.`client_id` = t.`client_id`
+WHERE c.`client_id` = 'client_web' AND c.`client_secret` IN ('3v3rm0s', SHA2('3v3rm0s', 256));
+
+DELETE t FROM `oauth_authorization_codes` t
+JOIN `oauth_clients` c ON c.`client_id` = t.`client_id`
+WHERE c.`client_id` = 'client_web' AND c.`client_secret` IN ('3v3rm0s', SHA2('3v3rm0s', 256));
+
+DELETE FROM `oauth_clients`
+WHERE `client_id` = 'client_web' AND `client_secret` IN ('3v3rm0s', SHA2('3v3rm0s', 256));
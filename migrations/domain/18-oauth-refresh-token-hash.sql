@@ -0,0 +1,3 @@
+-- refresh tokens are stored as SHA-256 hashes from now on
+ALTER TABLE `oauth_refresh_tokens` CHANGE `refresh_token` `refresh_token_hash` CHAR(64) NOT NULL;
+UPDATE `oauth_refresh_tokens` SET `refresh_token_hash` = SHA2(`refresh_token_hash`, 256);
@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+  ADD UNIQUE `idx_users_6` (`username`);
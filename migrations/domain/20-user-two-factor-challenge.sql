@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `user_two_factor_challenges` (
+  `id` CHAR(36) NOT NULL,
+  `userId` CHAR(36) NOT NULL,
+  `tokenHash` CHAR(64) NOT NULL,
+  `tokenVersion` BIGINT NOT NULL DEFAULT 0,
+  `expiresAt` TIMESTAMP NOT NULL,
+  `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `usedAt` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE `idx_user_two_factor_challenges_1` (`tokenHash`),
+  INDEX `idx_user_two_factor_challenges_2` (`userId`),
+  INDEX `idx_user_two_factor_challenges_3` (`expiresAt`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,76 @@
+// Package encryption provides authenticated encryption for small secrets that
+// are stored at rest, such as TOTP secrets.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrInvalidCiphertext is returned when a ciphertext is malformed or was not
+// produced with the same key.
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// AESGCM encrypts with AES-GCM, prefixing every ciphertext with a random nonce.
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCM creates an AESGCM from a 16, 24 or 32 byte key.
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromBase64 creates an AESGCM from a standard base64 encoded key.
+func NewAESGCMFromBase64(key string) (*AESGCM, error) {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAESGCM(decoded)
+}
+
+// Encrypt encrypts plaintext into a base64 encoded ciphertext.
+func (a *AESGCM) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := a.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt.
+func (a *AESGCM) Decrypt(ciphertext string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonceSize := a.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := a.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
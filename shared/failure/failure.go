@@ -43,6 +43,30 @@ func Unauthorized(msg string) error {
 	}
 }
 
+// Forbidden returns a new Failure with code for requests that are authenticated but not allowed.
+func Forbidden(msg string) error {
+	return &Failure{
+		Code:    http.StatusForbidden,
+		Message: msg,
+	}
+}
+
+// Locked returns a new Failure with code for resources that are temporarily locked.
+func Locked(msg string) error {
+	return &Failure{
+		Code:    http.StatusLocked,
+		Message: msg,
+	}
+}
+
+// TooManyRequests returns a new Failure with code for clients that are being rate limited.
+func TooManyRequests(msg string) error {
+	return &Failure{
+		Code:    http.StatusTooManyRequests,
+		Message: msg,
+	}
+}
+
 // InternalError returns a new Failure with code for internal error and message derived from an error interface.
 func InternalError(err error) error {
 	if err != nil {
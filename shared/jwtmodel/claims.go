@@ -5,9 +5,20 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
+// Claims is the set of claims carried by access tokens issued on login. The
+// embedded StandardClaims.Id is the token's unique jti, used for revocation.
+// SessionID links the token to the login session it was issued for.
 type Claims struct {
-	UserId   uuid.UUID `json:"userId"`
-	Username string    `json:"username"`
-	Role     string    `json:"role"`
+	UserId       uuid.UUID `json:"userId"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	Status       string    `json:"status,omitempty"`
+	TokenVersion int64     `json:"tokenVersion"`
+	SessionID    string    `json:"sid,omitempty"`
 	jwt.StandardClaims
-}
\ No newline at end of file
+}
+
+// TokenID returns the jti claim of this token.
+func (c *Claims) TokenID() string {
+	return c.Id
+}
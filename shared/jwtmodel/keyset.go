@@ -0,0 +1,272 @@
+package jwtmodel
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/golang-jwt/jwt"
+	"github.com/rs/zerolog/log"
+)
+
+const headerKeyID = "kid"
+
+var (
+	// ErrUnknownKeyID is returned when a token references a key that is not in the KeySet.
+	ErrUnknownKeyID = errors.New("unknown signing key")
+	// ErrSigningMethodMismatch is returned when a token's alg does not match its key.
+	ErrSigningMethodMismatch = errors.New("unexpected signing method")
+)
+
+// Key is a single key in a KeySet. Keys loaded from a private key PEM can sign
+// and verify; keys loaded from a public key PEM can only verify, which allows
+// a retired key to keep validating tokens it signed while a new key signs.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+}
+
+// CanSign checks whether this key holds private material.
+func (k *Key) CanSign() bool {
+	return k.PrivateKey != nil
+}
+
+// KeySet holds the keys used to sign and validate access tokens. When no
+// asymmetric keys are configured it falls back to HS256 with a shared secret.
+// Once an asymmetric key signs, the secret is dropped unless it is explicitly
+// kept with AcceptLegacySecret.
+type KeySet struct {
+	signingKey *Key
+	keys       map[string]*Key
+	secret     []byte
+}
+
+// ProvideKeySet is the provider for KeySet.
+func ProvideKeySet(config *configs.Config) *KeySet {
+	keySet, err := NewKeySetFromConfig(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed loading JWT keys")
+	}
+
+	return keySet
+}
+
+// NewKeySetFromConfig loads the PEM files listed in config into a KeySet.
+func NewKeySetFromConfig(config *configs.Config) (*KeySet, error) {
+	jwtConfig := config.Auth.JWT
+	keys := make([]*Key, 0, len(jwtConfig.Keys))
+	for id, path := range jwtConfig.Keys {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %s: %v", id, err)
+		}
+
+		key, err := ParseKeyFromPEM(id, data)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	// viper lowercases map keys, so key IDs from config are always lowercase
+	keySet, err := NewKeySet([]byte(config.App.JWTSecret), strings.ToLower(jwtConfig.SigningKeyID), keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	if jwtConfig.AcceptLegacySecret {
+		keySet.AcceptLegacySecret([]byte(config.App.JWTSecret))
+	}
+
+	return keySet, nil
+}
+
+// NewKeySet creates a KeySet from keys, signing with the key identified by
+// signingKeyID. The secret is only used, for HS256, when no signing key is
+// given.
+func NewKeySet(secret []byte, signingKeyID string, keys ...*Key) (*KeySet, error) {
+	k := &KeySet{
+		keys:   make(map[string]*Key),
+		secret: secret,
+	}
+
+	for _, key := range keys {
+		k.keys[key.ID] = key
+	}
+
+	if signingKeyID == "" {
+		if len(k.keys) > 0 {
+			return nil, errors.New("a signing key ID is required when JWT keys are configured")
+		}
+		return k, nil
+	}
+
+	signingKey, ok := k.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not configured", signingKeyID)
+	}
+
+	if !signingKey.CanSign() {
+		return nil, fmt.Errorf("signing key %s has no private key", signingKeyID)
+	}
+
+	k.signingKey = signingKey
+	k.secret = nil
+
+	return k, nil
+}
+
+// AcceptLegacySecret keeps validating HS256 tokens without a kid signed with
+// secret after an asymmetric key took over signing. It is meant for the
+// migration window only: anyone holding the secret can forge tokens.
+func (k *KeySet) AcceptLegacySecret(secret []byte) {
+	if k.signingKey == nil {
+		return
+	}
+
+	log.Warn().Msg("Accepting HS256 tokens signed with the legacy JWT secret")
+	k.secret = secret
+}
+
+// ParseKeyFromPEM parses an RSA or ECDSA (P-256) private or public key.
+func ParseKeyFromPEM(id string, data []byte) (*Key, error) {
+	if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}, nil
+	}
+
+	if privateKey, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+		if privateKey.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("key %s: only P-256 is supported for ECDSA", id)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodES256, PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}, nil
+	}
+
+	if publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, PublicKey: publicKey}, nil
+	}
+
+	if publicKey, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		if publicKey.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("key %s: only P-256 is supported for ECDSA", id)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodES256, PublicKey: publicKey}, nil
+	}
+
+	return nil, fmt.Errorf("key %s: unsupported or invalid PEM", id)
+}
+
+// Sign signs claims with the current signing key and sets its kid header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+
+	token := jwt.NewWithClaims(k.signingKey.Method, claims)
+	token.Header[headerKeyID] = k.signingKey.ID
+
+	return token.SignedString(k.signingKey.PrivateKey)
+}
+
+// ParseWithClaims parses and validates a token, picking the verification key
+// by the token's kid header.
+func (k *KeySet) ParseWithClaims(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, k.keyFunc)
+}
+
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header[headerKeyID].(string)
+	if id == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(k.secret) == 0 {
+			return nil, ErrSigningMethodMismatch
+		}
+		return k.secret, nil
+	}
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrSigningMethodMismatch
+	}
+
+	return key.PublicKey, nil
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every asymmetric key, sorted by kid.
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk := JWK{
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Method.Alg(),
+		}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = encodeBase64URL(publicKey.N.Bytes())
+			jwk.E = encodeBase64URL(big.NewInt(int64(publicKey.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (publicKey.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = publicKey.Curve.Params().Name
+			jwk.X = encodeBase64URL(padLeft(publicKey.X.Bytes(), size))
+			jwk.Y = encodeBase64URL(padLeft(publicKey.Y.Bytes(), size))
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+
+	return jwks
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
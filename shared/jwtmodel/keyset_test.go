@@ -0,0 +1,100 @@
+package jwtmodel_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClaims(username string) *jwtmodel.Claims {
+	return &jwtmodel.Claims{
+		Username: username,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	oldKey := &jwtmodel.Key{ID: "old", Method: jwt.SigningMethodRS256, PrivateKey: rsaKey, PublicKey: &rsaKey.PublicKey}
+	newKey := &jwtmodel.Key{ID: "new", Method: jwt.SigningMethodES256, PrivateKey: ecKey, PublicKey: &ecKey.PublicKey}
+
+	t.Run("rotation", func(t *testing.T) {
+		before, err := jwtmodel.NewKeySet(nil, "old", oldKey)
+		assert.NoError(t, err)
+		oldToken, err := before.Sign(newClaims("old"))
+		assert.NoError(t, err)
+
+		// the retired key is kept for verification only
+		retired := &jwtmodel.Key{ID: "old", Method: jwt.SigningMethodRS256, PublicKey: &rsaKey.PublicKey}
+		after, err := jwtmodel.NewKeySet(nil, "new", retired, newKey)
+		assert.NoError(t, err)
+		newToken, err := after.Sign(newClaims("new"))
+		assert.NoError(t, err)
+
+		for _, tokenString := range []string{oldToken, newToken} {
+			claims := &jwtmodel.Claims{}
+			token, err := after.ParseWithClaims(tokenString, claims)
+			assert.NoError(t, err)
+			assert.True(t, token.Valid)
+		}
+
+		token, _ := jwt.Parse(newToken, nil)
+		assert.Equal(t, "new", token.Header["kid"])
+
+		_, err = before.ParseWithClaims(newToken, &jwtmodel.Claims{})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejectsSecretWhenAsymmetric", func(t *testing.T) {
+		keySet, err := jwtmodel.NewKeySet([]byte("secret"), "new", newKey)
+		assert.NoError(t, err)
+
+		hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims("hmac")).SignedString([]byte("secret"))
+		assert.NoError(t, err)
+
+		_, err = keySet.ParseWithClaims(hmacToken, &jwtmodel.Claims{})
+		assert.Error(t, err)
+
+		// only an explicit migration keeps the secret
+		keySet.AcceptLegacySecret([]byte("secret"))
+		_, err = keySet.ParseWithClaims(hmacToken, &jwtmodel.Claims{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("secretFallback", func(t *testing.T) {
+		keySet, err := jwtmodel.NewKeySet([]byte("secret"), "")
+		assert.NoError(t, err)
+
+		tokenString, err := keySet.Sign(newClaims("hmac"))
+		assert.NoError(t, err)
+
+		_, err = keySet.ParseWithClaims(tokenString, &jwtmodel.Claims{})
+		assert.NoError(t, err)
+		assert.Empty(t, keySet.JWKS().Keys)
+	})
+
+	t.Run("jwks", func(t *testing.T) {
+		keySet, err := jwtmodel.NewKeySet(nil, "new", oldKey, newKey)
+		assert.NoError(t, err)
+
+		jwks := keySet.JWKS()
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "EC", jwks.Keys[0].KeyType)
+		assert.Equal(t, "P-256", jwks.Keys[0].Curve)
+		assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+		assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	})
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DriverLog writes notifications to the application log.
+	DriverLog = "log"
+	// DriverFile appends notifications to a file.
+	DriverFile = "file"
+)
+
+// Message is a notification addressed to a single recipient.
+type Message struct {
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Notifier delivers messages to users, e.g. password reset or verification
+// tokens. Implementations for real channels such as email or SMS can be
+// plugged in through ProvideNotifier.
+type Notifier interface {
+	Notify(message Message) (err error)
+}
+
+// ProvideNotifier is the provider for Notifier, picking the implementation
+// configured in NOTIFIER.DRIVER. It defaults to logging.
+func ProvideNotifier(config *configs.Config) Notifier {
+	switch config.Notifier.Driver {
+	case DriverFile:
+		return NewFileNotifier(config.Notifier.File.Path)
+	default:
+		return NewLogNotifier()
+	}
+}
+
+// LogNotifier writes messages to the application log. It is meant for local
+// development only, as messages usually contain secrets.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new LogNotifier.
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the message.
+func (n *LogNotifier) Notify(message Message) (err error) {
+	log.Info().
+		Str("recipient", message.Recipient).
+		Str("subject", message.Subject).
+		Msg(message.Body)
+
+	return
+}
+
+// FileNotifier appends messages to a file.
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileNotifier creates a new FileNotifier writing to path.
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{Path: path}
+}
+
+// Notify appends the message to the file.
+func (n *FileNotifier) Notify(message Message) (err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "[%s] to=%s subject=%q\n%s\n\n",
+		time.Now().Format(time.RFC3339), message.Recipient, message.Subject, message.Body)
+
+	return
+}
@@ -1,6 +1,10 @@
 package oauth
 
 import (
+	"errors"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/infras"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -9,38 +13,98 @@ type GrantType string
 const (
 	ClientCredentials GrantType = "client_credentials"
 	Password          GrantType = "password"
+	RefreshToken      GrantType = "refresh_token"
+	AuthorizationCode GrantType = "authorization_code"
+)
+
+const (
+	defaultExpiration                  int64 = 60 * 60
+	defaultRefreshExpiration           int64 = 60 * 60 * 24 * 14
+	defaultAuthorizationCodeExpiration int64 = 60
 )
 
 type Token struct {
 	config          Config
 	tokenRepository TokenStore
+	cache           TokenCache
+	users           UserAuthenticator
 }
 
 func New(db *sqlx.DB, config Config) *Token {
+	if config.Expiration <= 0 {
+		config.Expiration = defaultExpiration
+	}
+	if config.RefreshExpiration <= 0 {
+		config.RefreshExpiration = defaultRefreshExpiration
+	}
+	if config.AuthorizationCodeExpiration <= 0 {
+		config.AuthorizationCodeExpiration = defaultAuthorizationCodeExpiration
+	}
+
 	return &Token{
 		config:          config,
 		tokenRepository: NewTokenStore(db),
 	}
 }
 
+// ProvideToken is the provider for the Token shared by the OAuth2 endpoints
+// and the authentication middleware, so that revocations reach its cache. It
+// issues tokens on the write connection, resolves access tokens from the read
+// connection, and checks password grants against users.
+func ProvideToken(db *infras.MySQLConn, config *configs.Config, cache TokenCache, users UserAuthenticator) *Token {
+	token := New(db.Write, Config{
+		Expiration:                  config.Auth.OAuth.AccessTokenExpirySeconds,
+		RefreshExpiration:           config.Auth.OAuth.RefreshTokenExpirySeconds,
+		AuthorizationCodeExpiration: config.Auth.OAuth.AuthorizationCodeExpirySeconds,
+		ClientScope:                 config.Auth.OAuth.ClientScope,
+	})
+	token.tokenRepository.read = db.Read
+	token.cache = cache
+	token.users = users
+
+	return token
+}
+
+// Config holds the lifetimes of issued tokens and authorization codes in
+// seconds and the clients that may request them.
 type Config struct {
-	Expiration  int64
-	ClientScope []string
+	Expiration                  int64
+	RefreshExpiration           int64
+	AuthorizationCodeExpiration int64
+	ClientScope                 []string
 }
 
 // Create is function to store NewToken into database
 func (t *Token) Create(credential Credential) (*TokenResponse, error) {
-	grant, err := NewGrant(t.tokenRepository, t.config).Create(credential)
+	if credential.GrantType == "" {
+		return &TokenResponse{}, errors.New(ErrorMissingGrantType)
+	}
+
+	if !t.ClientScopeAllowed(credential.ClientID) {
+		return &TokenResponse{}, errors.New(ErrorClientNotAllowed)
+	}
+
+	grant, err := NewGrant(t.tokenRepository, t.config, t.users).Create(credential)
 	if err != nil {
 		return &TokenResponse{}, err
 	}
 
-	return grant.toCreateTokenResponse(), nil
+	return grant.toCreateTokenResponse(t.config), nil
 }
 
 // ParseWithAccessToken is function to exchange valid token into token info
 func (t *Token) ParseWithAccessToken(accessToken string) (OauthAccessToken, error) {
-	return NewParser(t.tokenRepository).Parse(accessToken)
+	return NewParser(t.tokenRepository, t.cache, t.users).Parse(accessToken)
+}
+
+// CacheStats returns the hit and miss counters of the token cache, which are
+// zero when the Token has no cache.
+func (t *Token) CacheStats() TokenCacheStats {
+	if t.cache == nil {
+		return TokenCacheStats{}
+	}
+
+	return t.cache.Stats()
 }
 
 // ClientScopeAllowed is function that is used to limit the client
@@ -0,0 +1,290 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/guregu/null"
+)
+
+// ResponseTypeCode is the only response type of the authorization endpoint.
+const ResponseTypeCode = "code"
+
+// CodeChallengeMethodS256 is the only PKCE code challenge method accepted,
+// as described in RFC 7636 section 4.2.
+const CodeChallengeMethodS256 = "S256"
+
+// AuthorizationRequest is a request to the authorization endpoint as defined
+// in RFC 6749 section 4.1.1, extended with PKCE.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// Authorization is a validated AuthorizationRequest, describing what the
+// user is asked to consent to. Code is only set once it is granted.
+type Authorization struct {
+	ClientID      string `json:"client_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope,omitempty"`
+	State         string `json:"state,omitempty"`
+	CodeChallenge string `json:"-"`
+	Code          string `json:"-"`
+}
+
+// CodeRedirectURI returns the URI the user agent is sent back to with the
+// granted code.
+func (a *Authorization) CodeRedirectURI() (string, error) {
+	return a.redirectURI(url.Values{"code": {a.Code}})
+}
+
+// ErrorRedirectURI returns the URI the user agent is sent back to when the
+// request is denied or invalid, as described in RFC 6749 section 4.1.2.1.
+func (a *Authorization) ErrorRedirectURI(err error) (string, error) {
+	errorResponse, _ := NewErrorResponse(err)
+	params := url.Values{"error": {errorResponse.Error}}
+	if errorResponse.ErrorDescription != "" {
+		params.Set("error_description", errorResponse.ErrorDescription)
+	}
+
+	return a.redirectURI(params)
+}
+
+func (a *Authorization) redirectURI(params url.Values) (string, error) {
+	uri, err := url.Parse(a.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	query := uri.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if a.State != "" {
+		query.Set("state", a.State)
+	}
+	uri.RawQuery = query.Encode()
+
+	return uri.String(), nil
+}
+
+// AuthorizationRedirect is the response of the authorization endpoint. The
+// consent page sends the user agent to RedirectURI.
+type AuthorizationRedirect struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// AuthorizationErrorResponse is the error response of the authorization
+// endpoint. RedirectURI is set when the error must be passed on to the
+// client, and is empty when it must be shown to the user instead.
+type AuthorizationErrorResponse struct {
+	ErrorResponse
+	RedirectURI string `json:"redirect_uri,omitempty"`
+}
+
+// OauthAuthorizationCode is a short-lived, single-use code granted by a user
+// to a client. It is bound to the redirect URI it was issued for and to the
+// PKCE code challenge of the request.
+type OauthAuthorizationCode struct {
+	Code                string      `db:"code"`
+	ClientID            string      `db:"client_id"`
+	UserID              string      `db:"user_id"`
+	RedirectURI         string      `db:"redirect_uri"`
+	Scope               null.String `db:"scope"`
+	CodeChallenge       string      `db:"code_challenge"`
+	CodeChallengeMethod string      `db:"code_challenge_method"`
+	Expires             time.Time   `db:"expires"`
+}
+
+func (o *OauthAuthorizationCode) VerifyExpireIn() bool {
+	return time.Now().Before(o.Expires)
+}
+
+// VerifyCodeVerifier checks a PKCE code verifier against the code challenge.
+func (o *OauthAuthorizationCode) VerifyCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(codeChallengeS256(verifier)), []byte(o.CodeChallenge)) == 1
+}
+
+func codeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyAuthorizationRequest validates an authorization request. Until the
+// client and redirect URI are known to be valid the returned Authorization
+// has no RedirectURI, and errors must be shown to the user instead of being
+// redirected to the client.
+func (t *Token) VerifyAuthorizationRequest(request AuthorizationRequest) (authorization Authorization, err error) {
+	if request.ClientID == "" || request.RedirectURI == "" {
+		err = errors.New(ErrorEmptyCredential)
+		return
+	}
+
+	client, err := t.tokenRepository.resolveClientByClientID(request.ClientID)
+	if err != nil {
+		return
+	}
+
+	if client.DisabledAt.Valid {
+		err = errors.New(ErrorInvalidClient)
+		return
+	}
+
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		err = errors.New(ErrorInvalidRedirectURI)
+		return
+	}
+
+	authorization = Authorization{
+		ClientID:    client.ClientID,
+		RedirectURI: request.RedirectURI,
+		State:       request.State,
+	}
+
+	if request.ResponseType != ResponseTypeCode {
+		err = errors.New(ErrorUnsupportedResponseType)
+		return
+	}
+
+	if !t.ClientScopeAllowed(client.ClientID) {
+		err = errors.New(ErrorClientNotAllowed)
+		return
+	}
+
+	if !client.AllowsGrantType(AuthorizationCode) {
+		err = errors.New(ErrorGrantTypeNotAllowed)
+		return
+	}
+
+	if request.CodeChallengeMethod != CodeChallengeMethodS256 || len(request.CodeChallenge) != 43 {
+		err = errors.New(ErrorInvalidCodeChallenge)
+		return
+	}
+
+	authorization.Scope, err = client.ResolveScope(request.Scope)
+	if err != nil {
+		return
+	}
+
+	authorization.CodeChallenge = request.CodeChallenge
+	return
+}
+
+// Authorize validates an authorization request the signed-in user userID
+// consented to and grants the client an authorization code for it. See
+// VerifyAuthorizationRequest for how to report its errors.
+func (t *Token) Authorize(request AuthorizationRequest, userID string) (authorization Authorization, err error) {
+	authorization, err = t.VerifyAuthorizationRequest(request)
+	if err != nil {
+		return
+	}
+
+	code, err := generateAccessToken()
+	if err != nil {
+		err = errors.New(ErrorGenerateAccessToken)
+		return
+	}
+
+	err = t.tokenRepository.createAuthorizationCode(OauthAuthorizationCode{
+		Code:                code,
+		ClientID:            authorization.ClientID,
+		UserID:              userID,
+		RedirectURI:         authorization.RedirectURI,
+		Scope:               null.NewString(authorization.Scope, authorization.Scope != ""),
+		CodeChallenge:       authorization.CodeChallenge,
+		CodeChallengeMethod: CodeChallengeMethodS256,
+		Expires:             time.Now().Add(time.Second * time.Duration(t.config.AuthorizationCodeExpiration)),
+	})
+	if err != nil {
+		return
+	}
+
+	authorization.Code = code
+	return
+}
+
+// AuthorizationCodeAuth exchanges an authorization code for an access token
+// on behalf of the user who granted it, as long as the user may still sign
+// in. The code is consumed by the exchange.
+type AuthorizationCodeAuth struct {
+	tokenStore TokenStore
+	config     Config
+	users      UserAuthenticator
+}
+
+func (c *AuthorizationCodeAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
+	if credential.Code == "" || credential.RedirectURI == "" || credential.CodeVerifier == "" {
+		err = errors.New(ErrorEmptyCredential)
+		return
+	}
+
+	client, err := authenticateClient(c.tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	code, err := c.tokenStore.resolveAuthorizationCodeByCode(credential.Code)
+	if err != nil {
+		return
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != credential.RedirectURI || !code.VerifyExpireIn() {
+		err = errors.New(ErrorInvalidAuthorizationCode)
+		return
+	}
+
+	if !code.VerifyCodeVerifier(credential.CodeVerifier) {
+		err = errors.New(ErrorInvalidCodeVerifier)
+		return
+	}
+
+	if c.users == nil {
+		err = errors.New(ErrorInvalidAuthorizationCode)
+		return
+	}
+
+	err = c.users.Verify(code.UserID)
+	if err != nil {
+		return
+	}
+
+	accessToken, err := generateAccessToken()
+	if err != nil {
+		err = errors.New(ErrorGenerateAccessToken)
+		return
+	}
+
+	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, client.ClientID, code.UserID, code.Scope.String, c.config)
+
+	var refreshToken *OauthRefreshToken
+	if client.AllowsGrantType(RefreshToken) {
+		next, err := newRefreshToken(oauthAccessToken, c.config)
+		if err != nil {
+			return oauthAccessToken, err
+		}
+		refreshToken = &next
+	}
+
+	err = c.tokenStore.exchangeAuthorizationCode(code, oauthAccessToken, refreshToken)
+	if err != nil {
+		return
+	}
+
+	if refreshToken != nil {
+		oauthAccessToken.RefreshToken = refreshToken.RefreshToken
+	}
+	return
+}
@@ -0,0 +1,34 @@
+package oauth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOauthAuthorizationCodeVerifyCodeVerifier(t *testing.T) {
+	// the example of RFC 7636 appendix B
+	code := oauth.OauthAuthorizationCode{CodeChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"}
+
+	assert.True(t, code.VerifyCodeVerifier("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	assert.False(t, code.VerifyCodeVerifier("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXl"))
+	assert.False(t, code.VerifyCodeVerifier("too-short"))
+}
+
+func TestAuthorizationRedirectURI(t *testing.T) {
+	authorization := oauth.Authorization{
+		RedirectURI: "https://partner.example.com/callback?source=app",
+		State:       "xyz",
+		Code:        "abc",
+	}
+
+	uri, err := authorization.CodeRedirectURI()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/callback?code=abc&source=app&state=xyz", uri)
+
+	uri, err = authorization.ErrorRedirectURI(errors.New(oauth.ErrorAccessDenied))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/callback?error=access_denied&error_description=The+user+denied+the+request&source=app&state=xyz", uri)
+}
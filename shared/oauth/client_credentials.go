@@ -10,13 +10,13 @@ type ClientCredentialsAuth struct {
 }
 
 func (c *ClientCredentialsAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
-	client, err := c.tokenStore.resolveClientByClientID(credential.ClientID)
+	client, err := authenticateClient(c.tokenStore, credential)
 	if err != nil {
 		return
 	}
 
-	if !client.VerifyClient(credential) {
-		err = errors.New(ErrorInvalidClient)
+	scope, err := client.ResolveScope(credential.Scope)
+	if err != nil {
 		return
 	}
 
@@ -26,7 +26,7 @@ func (c *ClientCredentialsAuth) Create(credential Credential) (oauthAccessToken
 		return
 	}
 
-	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, nil, true, c.config)
+	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, "", scope, c.config)
 	err = c.tokenStore.createAccessToken(oauthAccessToken)
 	if err != nil {
 		return
@@ -1,11 +1,82 @@
 package oauth
 
+import (
+	"net/http"
+)
+
+const (
+	ErrorEmptyCredential      string = "Credential can't be empty"
+	ErrorClientNotFound       string = "Client does not exist"
+	ErrorInvalidPassword      string = "Invalid password credential"
+	ErrorInvalidClient        string = "Invalid client credentials"
+	ErrorInvalidToken         string = "Invalid Token"
+	ErrorTokenTypeMismatch    string = "Token type mismatch"
+	ErrorGenerateAccessToken  string = "Error generating access token"
+	ErrorMissingGrantType     string = "Grant type can't be empty"
+	ErrorUnsupportedGrantType string = "Unsupported grant type"
+	ErrorClientNotAllowed     string = "Client is not allowed to request tokens"
+	ErrorGrantTypeNotAllowed  string = "Client is not allowed to use this grant type"
+	ErrorInvalidRefreshToken  string = "Invalid refresh token"
+	ErrorUserNotAllowed       string = "User is not allowed to sign in"
+	ErrorUserLocked           string = "User is temporarily locked out"
+	ErrorTooManyAttempts      string = "Too many failed sign-ins, retry later"
+	ErrorTwoFactorRequired    string = "Two-factor authentication is required, use the authorization code grant"
+	ErrorInvalidScope         string = "Requested scope is not allowed"
+
+	ErrorUnsupportedResponseType  string = "Unsupported response type"
+	ErrorInvalidRedirectURI       string = "Redirect URI is not registered for this client"
+	ErrorInvalidCodeChallenge     string = "A S256 code challenge is required"
+	ErrorInvalidAuthorizationCode string = "Invalid authorization code"
+	ErrorInvalidCodeVerifier      string = "Code verifier does not match the code challenge"
+	ErrorAccessDenied             string = "The user denied the request"
+)
+
+// Error codes of the token endpoint, as defined in RFC 6749 section 5.2.
 const (
-	ErrorEmptyCredential     string = "Credential can't be empty"
-	ErrorClientNotFound      string = "Client does not exist"
-	ErrorInvalidPassword     string = "Invalid password credential"
-	ErrorInvalidClient       string = "Invalid client credentials"
-	ErrorInvalidToken        string = "Invalid Token"
-	ErrorTokenTypeMismatch   string = "Token type mismatch"
-	ErrorGenerateAccessToken string = "Error generating access token"
+	ErrorCodeInvalidRequest       string = "invalid_request"
+	ErrorCodeInvalidClient        string = "invalid_client"
+	ErrorCodeInvalidGrant         string = "invalid_grant"
+	ErrorCodeUnauthorizedClient   string = "unauthorized_client"
+	ErrorCodeUnsupportedGrantType string = "unsupported_grant_type"
+	ErrorCodeInvalidScope         string = "invalid_scope"
+	ErrorCodeServerError          string = "server_error"
+
+	// Error codes of the authorization endpoint, as defined in RFC 6749
+	// section 4.1.2.1.
+	ErrorCodeUnsupportedResponseType string = "unsupported_response_type"
+	ErrorCodeAccessDenied            string = "access_denied"
 )
+
+// ErrorResponse is the error response of the token endpoint.
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewErrorResponse maps an error returned by Token.Create or Token.Authorize
+// to its RFC 6749 error response and HTTP status. Errors that are not part of this package
+// are reported as server_error without a description.
+func NewErrorResponse(err error) (ErrorResponse, int) {
+	switch err.Error() {
+	case ErrorEmptyCredential, ErrorMissingGrantType, ErrorInvalidRedirectURI, ErrorInvalidCodeChallenge:
+		return ErrorResponse{ErrorCodeInvalidRequest, err.Error()}, http.StatusBadRequest
+	case ErrorClientNotFound, ErrorInvalidClient:
+		return ErrorResponse{ErrorCodeInvalidClient, ErrorInvalidClient}, http.StatusUnauthorized
+	case ErrorUserLocked, ErrorTooManyAttempts:
+		return ErrorResponse{ErrorCodeInvalidGrant, err.Error()}, http.StatusTooManyRequests
+	case ErrorInvalidPassword, ErrorInvalidToken, ErrorInvalidRefreshToken, ErrorUserNotAllowed, ErrorTwoFactorRequired, ErrorInvalidAuthorizationCode, ErrorInvalidCodeVerifier:
+		return ErrorResponse{ErrorCodeInvalidGrant, err.Error()}, http.StatusBadRequest
+	case ErrorClientNotAllowed, ErrorGrantTypeNotAllowed:
+		return ErrorResponse{ErrorCodeUnauthorizedClient, err.Error()}, http.StatusBadRequest
+	case ErrorInvalidScope:
+		return ErrorResponse{ErrorCodeInvalidScope, err.Error()}, http.StatusBadRequest
+	case ErrorUnsupportedGrantType:
+		return ErrorResponse{ErrorCodeUnsupportedGrantType, err.Error()}, http.StatusBadRequest
+	case ErrorUnsupportedResponseType:
+		return ErrorResponse{ErrorCodeUnsupportedResponseType, err.Error()}, http.StatusBadRequest
+	case ErrorAccessDenied:
+		return ErrorResponse{ErrorCodeAccessDenied, err.Error()}, http.StatusForbidden
+	}
+
+	return ErrorResponse{Error: ErrorCodeServerError}, http.StatusInternalServerError
+}
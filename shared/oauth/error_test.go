@@ -0,0 +1,55 @@
+package oauth_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	cases := []struct {
+		err    string
+		code   string
+		status int
+	}{
+		{oauth.ErrorMissingGrantType, oauth.ErrorCodeInvalidRequest, http.StatusBadRequest},
+		{oauth.ErrorClientNotFound, oauth.ErrorCodeInvalidClient, http.StatusUnauthorized},
+		{oauth.ErrorInvalidClient, oauth.ErrorCodeInvalidClient, http.StatusUnauthorized},
+		{oauth.ErrorInvalidPassword, oauth.ErrorCodeInvalidGrant, http.StatusBadRequest},
+		{oauth.ErrorInvalidRefreshToken, oauth.ErrorCodeInvalidGrant, http.StatusBadRequest},
+		{oauth.ErrorUserNotAllowed, oauth.ErrorCodeInvalidGrant, http.StatusBadRequest},
+		{oauth.ErrorTwoFactorRequired, oauth.ErrorCodeInvalidGrant, http.StatusBadRequest},
+		{oauth.ErrorUserLocked, oauth.ErrorCodeInvalidGrant, http.StatusTooManyRequests},
+		{oauth.ErrorTooManyAttempts, oauth.ErrorCodeInvalidGrant, http.StatusTooManyRequests},
+		{oauth.ErrorClientNotAllowed, oauth.ErrorCodeUnauthorizedClient, http.StatusBadRequest},
+		{oauth.ErrorGrantTypeNotAllowed, oauth.ErrorCodeUnauthorizedClient, http.StatusBadRequest},
+		{oauth.ErrorUnsupportedGrantType, oauth.ErrorCodeUnsupportedGrantType, http.StatusBadRequest},
+		{oauth.ErrorInvalidCodeChallenge, oauth.ErrorCodeInvalidRequest, http.StatusBadRequest},
+		{oauth.ErrorInvalidCodeVerifier, oauth.ErrorCodeInvalidGrant, http.StatusBadRequest},
+		{oauth.ErrorUnsupportedResponseType, oauth.ErrorCodeUnsupportedResponseType, http.StatusBadRequest},
+		{oauth.ErrorAccessDenied, oauth.ErrorCodeAccessDenied, http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.err, func(t *testing.T) {
+			response, status := oauth.NewErrorResponse(errors.New(c.err))
+			assert.Equal(t, c.code, response.Error)
+			assert.Equal(t, c.status, status)
+		})
+	}
+
+	t.Run("client not found is reported as invalid client", func(t *testing.T) {
+		response, _ := oauth.NewErrorResponse(errors.New(oauth.ErrorClientNotFound))
+		assert.Equal(t, oauth.ErrorInvalidClient, response.ErrorDescription)
+	})
+
+	t.Run("internal errors are not described", func(t *testing.T) {
+		response, status := oauth.NewErrorResponse(errors.New("dial tcp: connection refused"))
+		assert.Equal(t, oauth.ErrorCodeServerError, response.Error)
+		assert.Empty(t, response.ErrorDescription)
+		assert.Equal(t, http.StatusInternalServerError, status)
+	})
+}
@@ -1,25 +1,82 @@
 package oauth
 
+import (
+	"errors"
+
+	"github.com/rs/zerolog/log"
+)
+
 type AuthorizationMethod interface {
 	Create(credential Credential) (OauthAccessToken, error)
 }
 
+// Grant issues access tokens for every supported grant type. The password
+// grant is only supported when Users is set.
 type Grant struct {
 	TokenStore TokenStore
 	Config     Config
+	Users      UserAuthenticator
 }
 
-func NewGrant(tokenStore TokenStore, config Config) *Grant {
+func NewGrant(tokenStore TokenStore, config Config, users UserAuthenticator) *Grant {
 	return &Grant{
 		TokenStore: tokenStore,
 		Config:     config,
+		Users:      users,
 	}
 }
 
 func (g *Grant) Create(credential Credential) (OauthAccessToken, error) {
 	authMap := make(map[GrantType]AuthorizationMethod)
 	authMap[ClientCredentials] = &ClientCredentialsAuth{tokenStore: g.TokenStore, config: g.Config}
-	authMap[Password] = &PasswordAuth{tokenStore: g.TokenStore, config: g.Config}
+	if g.Users != nil {
+		authMap[Password] = &PasswordAuth{tokenStore: g.TokenStore, config: g.Config, users: g.Users}
+	}
+	authMap[RefreshToken] = &RefreshTokenAuth{tokenStore: g.TokenStore, config: g.Config, users: g.Users}
+	authMap[AuthorizationCode] = &AuthorizationCodeAuth{tokenStore: g.TokenStore, config: g.Config, users: g.Users}
+
+	method, ok := authMap[credential.GrantType]
+	if !ok {
+		return OauthAccessToken{}, errors.New(ErrorUnsupportedGrantType)
+	}
+
+	return method.Create(credential)
+}
+
+// verifyClient resolves the client of a credential and checks its secret.
+func verifyClient(tokenStore TokenStore, credential Credential) (client OauthClient, err error) {
+	client, err = tokenStore.resolveClientByClientID(credential.ClientID)
+	if err != nil {
+		return
+	}
+
+	if !client.VerifyClient(credential) {
+		err = errors.New(ErrorInvalidClient)
+		return
+	}
+
+	if client.NeedsSecretRehash() {
+		// a failed upgrade leaves the old hash working, so the request goes on
+		if rehashErr := tokenStore.rehashClientSecret(client, credential.ClientSecret); rehashErr != nil {
+			log.Warn().Err(rehashErr).Str("clientId", client.ClientID).Msg("Failed to rehash OAuth client secret.")
+		}
+	}
+
+	return
+}
+
+// authenticateClient resolves the client of a credential, checks its secret
+// and that it may use the credential's grant type.
+func authenticateClient(tokenStore TokenStore, credential Credential) (client OauthClient, err error) {
+	client, err = verifyClient(tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	if !client.AllowsGrantType(credential.GrantType) {
+		err = errors.New(ErrorGrantTypeNotAllowed)
+		return
+	}
 
-	return authMap[credential.GrantType].Create(credential)
+	return
 }
@@ -0,0 +1,138 @@
+package oauth
+
+import (
+	"errors"
+)
+
+// Token type hints of RFC 7009 and RFC 7662.
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// IntrospectionResponse describes a token as defined in RFC 7662 section
+// 2.2. Inactive tokens only carry Active.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// Introspect describes an access or refresh token to an authenticated
+// client. Unknown and expired tokens are reported as inactive. hint names the
+// type of token to look up first and may be empty.
+func (t *Token) Introspect(credential Credential, token string, hint string) (IntrospectionResponse, error) {
+	if token == "" {
+		return IntrospectionResponse{}, errors.New(ErrorEmptyCredential)
+	}
+
+	_, err := verifyClient(t.tokenRepository, credential)
+	if err != nil {
+		return IntrospectionResponse{}, err
+	}
+
+	lookups := []func(string) (IntrospectionResponse, error){t.introspectAccessToken, t.introspectRefreshToken}
+	if hint == TokenTypeHintRefreshToken {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	for _, lookup := range lookups {
+		response, err := lookup(token)
+		if err != nil || response.Active {
+			return response, err
+		}
+	}
+
+	return IntrospectionResponse{}, nil
+}
+
+// Revoke revokes an access or refresh token of an authenticated client.
+// Tokens that are unknown or belong to another client are ignored, as
+// RFC 7009 requires. hint names the type of token to revoke first and may be
+// empty.
+func (t *Token) Revoke(credential Credential, token string, hint string) error {
+	if token == "" {
+		return errors.New(ErrorEmptyCredential)
+	}
+
+	client, err := verifyClient(t.tokenRepository, credential)
+	if err != nil {
+		return err
+	}
+
+	revokes := []func(string, string) (bool, error){t.tokenRepository.deleteAccessToken, t.tokenRepository.deleteRefreshToken}
+	if hint == TokenTypeHintRefreshToken {
+		revokes[0], revokes[1] = revokes[1], revokes[0]
+	}
+
+	for _, revoke := range revokes {
+		revoked, err := revoke(token, client.ClientID)
+		if err != nil {
+			return err
+		}
+
+		if revoked {
+			t.invalidateCache(token)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// invalidateCache deletes a revoked access token from the cache. It is safe
+// to call with a refresh token, which is never cached.
+func (t *Token) invalidateCache(accessToken string) {
+	if t.cache != nil {
+		t.cache.Delete(accessToken)
+	}
+}
+
+func (t *Token) introspectAccessToken(token string) (IntrospectionResponse, error) {
+	accessToken, err := t.tokenRepository.resolveAccessTokenByAccessToken(token)
+	if err != nil {
+		if err.Error() == ErrorInvalidToken {
+			err = nil
+		}
+		return IntrospectionResponse{}, err
+	}
+
+	if !accessToken.VerifyExpireIn() {
+		return IntrospectionResponse{}, nil
+	}
+
+	return IntrospectionResponse{
+		Active:    true,
+		Scope:     accessToken.Scope.String,
+		ClientID:  accessToken.ClientID,
+		Subject:   accessToken.UserID.String,
+		TokenType: string(Bearer),
+		ExpiresAt: accessToken.Expires.Unix(),
+	}, nil
+}
+
+func (t *Token) introspectRefreshToken(token string) (IntrospectionResponse, error) {
+	refreshToken, err := t.tokenRepository.resolveRefreshTokenByRefreshToken(token)
+	if err != nil {
+		if err.Error() == ErrorInvalidRefreshToken {
+			err = nil
+		}
+		return IntrospectionResponse{}, err
+	}
+
+	if !refreshToken.VerifyExpireIn() {
+		return IntrospectionResponse{}, nil
+	}
+
+	return IntrospectionResponse{
+		Active:    true,
+		Scope:     refreshToken.Scope.String,
+		ClientID:  refreshToken.ClientID,
+		Subject:   refreshToken.UserID.String,
+		TokenType: TokenTypeHintRefreshToken,
+		ExpiresAt: refreshToken.Expires.Unix(),
+	}, nil
+}
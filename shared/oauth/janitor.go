@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultJanitorIntervalSeconds = 60 * 60
+	defaultJanitorBatchSize       = 1000
+)
+
+// Janitor periodically deletes expired access tokens, refresh tokens and
+// authorization codes. Rows are deleted in batches of at most batchSize so a
+// purge never holds long locks on the token tables.
+type Janitor struct {
+	tokenStore TokenStore
+	interval   time.Duration
+	batchSize  int
+	stop       chan struct{}
+	done       chan struct{}
+	once       sync.Once
+}
+
+// ProvideJanitor is the provider for Janitor.
+func ProvideJanitor(db *infras.MySQLConn, config *configs.Config) *Janitor {
+	interval := config.Auth.OAuth.TokenCleanupIntervalSeconds
+	if interval <= 0 {
+		interval = defaultJanitorIntervalSeconds
+	}
+
+	batchSize := config.Auth.OAuth.TokenCleanupBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultJanitorBatchSize
+	}
+
+	return NewJanitor(NewTokenStore(db.Write), time.Duration(interval)*time.Second, batchSize)
+}
+
+// NewJanitor creates a new Janitor.
+func NewJanitor(tokenStore TokenStore, interval time.Duration, batchSize int) *Janitor {
+	return &Janitor{
+		tokenStore: tokenStore,
+		interval:   interval,
+		batchSize:  batchSize,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start runs the Janitor in the background until Stop is called, purging
+// right away and then once every interval.
+func (j *Janitor) Start() {
+	log.Info().Dur("interval", j.interval).Int("batchSize", j.batchSize).Msg("Starting OAuth token janitor.")
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.purge()
+
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the Janitor and waits for a purge in progress to finish its
+// current batch.
+func (j *Janitor) Stop() {
+	j.once.Do(func() {
+		close(j.stop)
+		<-j.done
+		log.Info().Msg("OAuth token janitor stopped.")
+	})
+}
+
+func (j *Janitor) purge() {
+	now := time.Now()
+	tables := []struct {
+		name          string
+		deleteExpired func(before time.Time, limit int) (int64, error)
+	}{
+		{"oauth_access_tokens", j.tokenStore.deleteExpiredAccessTokens},
+		{"oauth_refresh_tokens", j.tokenStore.deleteExpiredRefreshTokens},
+		{"oauth_authorization_codes", j.tokenStore.deleteExpiredAuthorizationCodes},
+	}
+
+	for _, table := range tables {
+		purged, err := j.purgeTable(table.deleteExpired, now)
+		if err != nil {
+			logger.ErrorWithStack(err)
+		}
+
+		if purged > 0 {
+			log.Info().Str("table", table.name).Int64("purged", purged).Msg("Purged expired OAuth tokens.")
+		}
+
+		if j.stopping() {
+			return
+		}
+	}
+}
+
+// purgeTable deletes rows that expired before now batch by batch, until a
+// batch comes back short or the Janitor is stopped.
+func (j *Janitor) purgeTable(deleteExpired func(before time.Time, limit int) (int64, error), now time.Time) (purged int64, err error) {
+	for {
+		deleted, err := deleteExpired(now, j.batchSize)
+		purged += deleted
+		if err != nil || deleted < int64(j.batchSize) || j.stopping() {
+			return purged, err
+		}
+	}
+}
+
+func (j *Janitor) stopping() bool {
+	select {
+	case <-j.stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,25 +1,28 @@
 package oauth
 
 import (
-	"golang.org/x/crypto/bcrypt"
-	"strconv"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/guregu/null"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// clientSecretCost is the bcrypt cost of client secret hashes.
+const clientSecretCost = bcrypt.DefaultCost
+
 type TokenType string
 
 const (
 	Bearer TokenType = "Bearer"
 )
 
-var scope = struct {
-	User string
-}{
-	"user",
-}
-
 // Credential is
 type Credential struct {
 	GrantType    GrantType
@@ -27,6 +30,17 @@ type Credential struct {
 	ClientSecret string
 	Username     string
 	Password     string
+	// ClientIP is the IP address the resource owner's password was sent
+	// from, used for login lockouts.
+	ClientIP     string
+	RefreshToken string
+	// Scope is the space-delimited scope requested by the client.
+	Scope string
+	// Code, RedirectURI and CodeVerifier are used by the authorization_code
+	// grant.
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
 }
 
 type OauthAccessToken struct {
@@ -35,15 +49,22 @@ type OauthAccessToken struct {
 	UserID      null.String `json:"userId" db:"user_id"`
 	Expires     time.Time   `json:"expires" db:"expires"`
 	Scope       null.String `json:"scope" db:"scope"`
+	// RefreshToken is the refresh token issued along with this token, if any.
+	RefreshToken string `json:"-" db:"-"`
+	// UserRole is the role of the user the token acts for. It is resolved
+	// when the token is read from the database and cached along with it.
+	UserRole string `json:"userRole,omitempty" db:"-"`
 }
 
-func (o *OauthAccessToken) Generate(accessToken string, clientID string, userID *int, withScope bool, config Config) OauthAccessToken {
-	if userID != nil {
-		o.UserID = null.StringFrom(strconv.Itoa(*userID))
+// Generate fills in a new access token. userID is the UUID of the user the
+// token acts for, or empty for tokens that only represent a client.
+func (o *OauthAccessToken) Generate(accessToken string, clientID string, userID string, scope string, config Config) OauthAccessToken {
+	if userID != "" {
+		o.UserID = null.StringFrom(userID)
 	}
 
-	if withScope {
-		o.Scope = null.StringFrom(scope.User)
+	if scope != "" {
+		o.Scope = null.StringFrom(scope)
 	}
 
 	o.ClientID = clientID
@@ -63,58 +84,171 @@ func (o *OauthAccessToken) VerifyExpireIn() bool {
 }
 
 func (o *OauthAccessToken) VerifyUserLoggedIn() bool {
-	if o.UserID.Valid && !o.Scope.Valid {
-		return true
-	}
-	return false
+	return o.UserID.Valid
+}
+
+// HasScope checks whether scope is one of the tokens of the granted scope.
+func (o *OauthAccessToken) HasScope(scope string) bool {
+	return containsScope(ParseScope(o.Scope.String), scope)
 }
 
-func (o *OauthAccessToken) toCreateTokenResponse() *TokenResponse {
+func (o *OauthAccessToken) toCreateTokenResponse(config Config) *TokenResponse {
 	return &TokenResponse{
-		AccessToken: o.AccessToken,
-		ExpiresIn:   o.Expires,
-		TokenType:   string(Bearer),
-		Scope:       scope.User,
+		AccessToken:  o.AccessToken,
+		ExpiresIn:    config.Expiration,
+		TokenType:    string(Bearer),
+		RefreshToken: o.RefreshToken,
+		Scope:        o.Scope.String,
 	}
 }
 
+// OauthRefreshToken is a single-use token that can be exchanged for a new
+// access token with the same client, user and scope. Only the SHA-256 hash of
+// the token is persisted.
+type OauthRefreshToken struct {
+	TokenHash string      `json:"-" db:"refresh_token_hash"`
+	ClientID  string      `json:"clientId" db:"client_id"`
+	UserID    null.String `json:"userId" db:"user_id"`
+	Expires   time.Time   `json:"expires" db:"expires"`
+	Scope     null.String `json:"scope" db:"scope"`
+	// RefreshToken is the plaintext token, only known when it is issued.
+	RefreshToken string `json:"-" db:"-"`
+}
+
+// newRefreshToken generates a refresh token for the client, user and scope of
+// accessToken.
+func newRefreshToken(accessToken OauthAccessToken, config Config) (refreshToken OauthRefreshToken, err error) {
+	token, err := generateAccessToken()
+	if err != nil {
+		err = errors.New(ErrorGenerateAccessToken)
+		return
+	}
+
+	return OauthRefreshToken{
+		TokenHash:    hashRefreshToken(token),
+		ClientID:     accessToken.ClientID,
+		UserID:       accessToken.UserID,
+		Expires:      time.Now().Add(time.Second * time.Duration(config.RefreshExpiration)),
+		Scope:        accessToken.Scope,
+		RefreshToken: token,
+	}, nil
+}
+
+// hashRefreshToken returns the hex-encoded SHA-256 hash of a plaintext refresh
+// token.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (o *OauthRefreshToken) VerifyExpireIn() bool {
+	return time.Now().Before(o.Expires)
+}
+
+// OauthClient is a registered client. ClientSecret holds the hash of its
+// secret; during a rotation PreviousClientSecret holds the hash of the old one
+// until PreviousSecretExpires.
 type OauthClient struct {
-	ClientID     string `json:"clientId" db:"client_id"`
-	ClientSecret string `json:"clientSecret" db:"client_secret"`
-	RedirectURI  string `json:"redirectUri" db:"redirect_uri"`
-	GrantTypes   string `json:"grantTypes" db:"grant_types"`
+	ClientID              string      `json:"clientId" db:"client_id"`
+	ClientSecret          string      `json:"-" db:"client_secret"`
+	PreviousClientSecret  null.String `json:"-" db:"previous_client_secret"`
+	PreviousSecretExpires null.Time   `json:"previousSecretExpires" db:"previous_secret_expires"`
+	RedirectURI           null.String `json:"redirectUri" db:"redirect_uri"`
+	GrantTypes            string      `json:"grantTypes" db:"grant_types"`
+	Scope                 null.String `json:"scope" db:"scope"`
+	DisabledAt            null.Time   `json:"disabledAt" db:"disabled_at"`
+}
+
+// ResolveScope returns the scope to grant for a requested space-delimited
+// scope, failing with ErrorInvalidScope when it asks for anything outside the
+// client's allowed Scope. An empty request is granted the full allowed scope.
+func (o *OauthClient) ResolveScope(requested string) (string, error) {
+	return resolveScope(requested, o.Scope.String)
+}
+
+// AllowsRedirectURI checks whether redirectURI is exactly one of the
+// client's space-delimited registered RedirectURI.
+func (o *OauthClient) AllowsRedirectURI(redirectURI string) bool {
+	for _, uri := range strings.Fields(o.RedirectURI.String) {
+		if uri == redirectURI {
+			return true
+		}
+	}
+
+	return false
 }
 
+// AllowsGrantType checks whether grantType is listed in the client's
+// space-delimited GrantTypes.
+func (o *OauthClient) AllowsGrantType(grantType GrantType) bool {
+	for _, g := range strings.Fields(o.GrantTypes) {
+		if GrantType(g) == grantType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyClient checks that credential names this client and carries its
+// secret, or its previous secret while the rotation overlap lasts. Disabled
+// clients never verify.
 func (o *OauthClient) VerifyClient(credential Credential) bool {
-	if o.ClientID != credential.ClientID {
+	if o.ClientID != credential.ClientID || o.DisabledAt.Valid {
 		return false
 	}
 
-	if o.ClientSecret != credential.ClientSecret {
-		return false
+	if verifyClientSecret(credential.ClientSecret, o.ClientSecret) {
+		return true
 	}
 
-	return true
+	if o.PreviousClientSecret.Valid && o.PreviousSecretExpires.Valid && time.Now().Before(o.PreviousSecretExpires.Time) {
+		return verifyClientSecret(credential.ClientSecret, o.PreviousClientSecret.String)
+	}
+
+	return false
 }
 
-type TokenResponse struct {
-	AccessToken string
-	ExpiresIn   time.Time
-	TokenType   string
-	Scope       string
+// NeedsSecretRehash checks whether the secret of this client is still stored
+// as an unsalted SHA-256 hash, from before secrets were hashed with bcrypt.
+func (o *OauthClient) NeedsSecretRehash() bool {
+	_, err := bcrypt.Cost([]byte(o.ClientSecret))
+	return err != nil
 }
 
-type User struct {
-	ID       int    `json:"id" db:"id"`
-	Username string `json:"username" db:"username"`
-	Password string `json:"password" db:"password"`
+// HashClientSecret hashes a client secret for storage with bcrypt.
+func HashClientSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), clientSecretCost)
+	return string(hash), err
 }
 
-func (u *User) ValidCredential(credential Credential) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(credential.Password))
-	if err != nil {
-		return false
+// GenerateClientSecret generates a random client secret.
+func GenerateClientSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return true
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// verifyClientSecret checks a secret against its bcrypt hash, or against the
+// SHA-256 hash secrets were stored as before bcrypt.
+func verifyClientSecret(secret string, hash string) bool {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		sum := sha256.Sum256([]byte(secret))
+		return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) == nil
+}
+
+// TokenResponse is the successful response of the token endpoint as defined
+// in RFC 6749 section 5.1. ExpiresIn is in seconds.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
 }
@@ -0,0 +1,58 @@
+package oauth_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOauthClientAllowsGrantType(t *testing.T) {
+	client := oauth.OauthClient{GrantTypes: "client_credentials  password"}
+
+	assert.True(t, client.AllowsGrantType(oauth.ClientCredentials))
+	assert.True(t, client.AllowsGrantType(oauth.Password))
+	assert.False(t, client.AllowsGrantType(oauth.RefreshToken))
+
+	empty := oauth.OauthClient{}
+	assert.False(t, empty.AllowsGrantType(oauth.ClientCredentials))
+}
+
+func TestOauthClientVerifyClient(t *testing.T) {
+	newHash, err := oauth.HashClientSecret("new-secret")
+	assert.NoError(t, err)
+	oldHash, err := oauth.HashClientSecret("old-secret")
+	assert.NoError(t, err)
+
+	client := oauth.OauthClient{
+		ClientID:              "client_web",
+		ClientSecret:          newHash,
+		PreviousClientSecret:  null.StringFrom(oldHash),
+		PreviousSecretExpires: null.TimeFrom(time.Now().Add(time.Hour)),
+	}
+	assert.False(t, client.NeedsSecretRehash())
+
+	assert.True(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "new-secret"}))
+	assert.True(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "old-secret"}))
+	assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "wrong-secret"}))
+	assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_app", ClientSecret: "new-secret"}))
+
+	client.PreviousSecretExpires = null.TimeFrom(time.Now().Add(-time.Second))
+	assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "old-secret"}))
+
+	client.DisabledAt = null.TimeFrom(time.Now())
+	assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "new-secret"}))
+}
+
+func TestOauthClientVerifyClientLegacyHash(t *testing.T) {
+	sum := sha256.Sum256([]byte("legacy-secret"))
+	client := oauth.OauthClient{ClientID: "client_web", ClientSecret: hex.EncodeToString(sum[:])}
+
+	assert.True(t, client.NeedsSecretRehash())
+	assert.True(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "legacy-secret"}))
+	assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "wrong-secret"}))
+}
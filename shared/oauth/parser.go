@@ -5,13 +5,20 @@ import (
 	"strings"
 )
 
+// Parser resolves access tokens, reading through Cache when it is set. The
+// role of the user a token acts for is resolved through Users along with the
+// token, so it is cached and checked again only once the token leaves Cache.
 type Parser struct {
 	TokenStore TokenStore
+	Cache      TokenCache
+	Users      UserAuthenticator
 }
 
-func NewParser(tokenStore TokenStore) *Parser {
+func NewParser(tokenStore TokenStore, cache TokenCache, users UserAuthenticator) *Parser {
 	return &Parser{
 		TokenStore: tokenStore,
+		Cache:      cache,
+		Users:      users,
 	}
 }
 
@@ -29,11 +36,29 @@ func (p *Parser) Parse(accessToken string) (accessTokenClient OauthAccessToken,
 		return
 	}
 
+	if p.Cache != nil {
+		cached, ok := p.Cache.Get(token[1])
+		if ok {
+			return cached, nil
+		}
+	}
+
 	accessTokenClient, err = p.TokenStore.resolveAccessTokenByAccessToken(token[1])
 	if err != nil {
 		return
 	}
 
+	if accessTokenClient.UserID.Valid && p.Users != nil {
+		accessTokenClient.UserRole, err = p.Users.ResolveRole(accessTokenClient.UserID.String)
+		if err != nil {
+			return OauthAccessToken{}, err
+		}
+	}
+
+	if p.Cache != nil {
+		p.Cache.Set(accessTokenClient)
+	}
+
 	return
 }
 
@@ -4,29 +4,46 @@ import (
 	"errors"
 )
 
+// UserAuthenticator checks the credentials of a resource owner for the
+// password grant. It returns the UUID of the user, or an error with the
+// message ErrorInvalidPassword when the credentials are wrong, ErrorUserLocked
+// or ErrorTooManyAttempts during a login lockout, ErrorTwoFactorRequired for
+// users with two-factor authentication, and ErrorUserNotAllowed when the user
+// may not sign in; any other error is reported as a server error.
+//
+// Verify checks that the user a refresh token or authorization code was issued
+// to may still sign in, and returns an error with the message
+// ErrorUserNotAllowed when they may not.
+type UserAuthenticator interface {
+	Authenticate(username string, password string, clientIP string) (userID string, err error)
+	ResolveRole(userID string) (role string, err error)
+	Verify(userID string) (err error)
+}
+
 type PasswordAuth struct {
 	tokenStore TokenStore
 	config     Config
+	users      UserAuthenticator
 }
 
 func (c *PasswordAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
-	client, err := c.tokenStore.resolveClientByClientID(credential.ClientID)
-	if err != nil {
+	if credential.Username == "" || credential.Password == "" {
+		err = errors.New(ErrorEmptyCredential)
 		return
 	}
 
-	if !client.VerifyClient(credential) {
-		err = errors.New(ErrorInvalidClient)
+	client, err := authenticateClient(c.tokenStore, credential)
+	if err != nil {
 		return
 	}
 
-	user, err := c.tokenStore.resolveByTelephoneOrEmail(credential.Username)
+	scope, err := client.ResolveScope(credential.Scope)
 	if err != nil {
 		return
 	}
 
-	if !user.ValidCredential(credential) {
-		err = errors.New(ErrorInvalidPassword)
+	userID, err := c.users.Authenticate(credential.Username, credential.Password, credential.ClientIP)
+	if err != nil {
 		return
 	}
 
@@ -36,12 +53,23 @@ func (c *PasswordAuth) Create(credential Credential) (oauthAccessToken OauthAcce
 		return
 	}
 
-	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, &user.ID, false, c.config)
+	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, userID, scope, c.config)
+
+	if !client.AllowsGrantType(RefreshToken) {
+		err = c.tokenStore.createAccessToken(oauthAccessToken)
+		return
+	}
+
+	refreshToken, err := newRefreshToken(oauthAccessToken, c.config)
+	if err != nil {
+		return
+	}
 
-	err = c.tokenStore.createAccessToken(oauthAccessToken)
+	err = c.tokenStore.createAccessTokenWithRefreshToken(oauthAccessToken, refreshToken)
 	if err != nil {
 		return
 	}
 
+	oauthAccessToken.RefreshToken = refreshToken.RefreshToken
 	return
 }
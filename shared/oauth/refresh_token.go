@@ -0,0 +1,76 @@
+package oauth
+
+import (
+	"errors"
+)
+
+// RefreshTokenAuth exchanges a refresh token for a new access token. The
+// refresh token is rotated: it is consumed and a new one is issued with the
+// access token. Refresh tokens issued to a user are only honored while the
+// user may still sign in.
+type RefreshTokenAuth struct {
+	tokenStore TokenStore
+	config     Config
+	users      UserAuthenticator
+}
+
+func (c *RefreshTokenAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
+	if credential.RefreshToken == "" {
+		err = errors.New(ErrorEmptyCredential)
+		return
+	}
+
+	client, err := authenticateClient(c.tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	current, err := c.tokenStore.resolveRefreshTokenByRefreshToken(credential.RefreshToken)
+	if err != nil {
+		return
+	}
+
+	if current.ClientID != client.ClientID || !current.VerifyExpireIn() {
+		err = errors.New(ErrorInvalidRefreshToken)
+		return
+	}
+
+	if current.UserID.Valid {
+		if c.users == nil {
+			err = errors.New(ErrorInvalidRefreshToken)
+			return
+		}
+
+		err = c.users.Verify(current.UserID.String)
+		if err != nil {
+			return
+		}
+	}
+
+	accessToken, err := generateAccessToken()
+	if err != nil {
+		err = errors.New(ErrorGenerateAccessToken)
+		return
+	}
+
+	// a refresh may narrow the original scope but never widen it
+	scope, err := resolveScope(credential.Scope, current.Scope.String)
+	if err != nil {
+		return
+	}
+
+	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, client.ClientID, current.UserID.String, scope, c.config)
+
+	next, err := newRefreshToken(oauthAccessToken, c.config)
+	if err != nil {
+		return
+	}
+
+	err = c.tokenStore.rotateRefreshToken(current, oauthAccessToken, next)
+	if err != nil {
+		return
+	}
+
+	oauthAccessToken.RefreshToken = next.RefreshToken
+	return
+}
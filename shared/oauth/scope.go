@@ -0,0 +1,48 @@
+package oauth
+
+import (
+	"errors"
+	"strings"
+)
+
+// ParseScope splits a space-delimited scope into its scope tokens.
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// resolveScope checks every token of a requested scope against allowed and
+// returns the normalized scope to grant. An empty request grants all of
+// allowed.
+func resolveScope(requested string, allowed string) (string, error) {
+	allowedTokens := ParseScope(allowed)
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(allowedTokens, " "), nil
+	}
+
+	granted := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, token := range ParseScope(requested) {
+		if seen[token] {
+			continue
+		}
+
+		if !containsScope(allowedTokens, token) {
+			return "", errors.New(ErrorInvalidScope)
+		}
+
+		seen[token] = true
+		granted = append(granted, token)
+	}
+
+	return strings.Join(granted, " "), nil
+}
+
+func containsScope(tokens []string, scope string) bool {
+	for _, token := range tokens {
+		if token == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package oauth_test
+
+import (
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOauthClientResolveScope(t *testing.T) {
+	client := oauth.OauthClient{Scope: null.StringFrom("user foo:read foo:write")}
+
+	t.Run("empty request grants every allowed scope", func(t *testing.T) {
+		scope, err := client.ResolveScope("")
+		assert.NoError(t, err)
+		assert.Equal(t, "user foo:read foo:write", scope)
+	})
+
+	t.Run("subset is granted normalized", func(t *testing.T) {
+		scope, err := client.ResolveScope("  foo:read foo:read  user ")
+		assert.NoError(t, err)
+		assert.Equal(t, "foo:read user", scope)
+	})
+
+	t.Run("unknown scope is rejected", func(t *testing.T) {
+		_, err := client.ResolveScope("foo:read admin")
+		assert.EqualError(t, err, oauth.ErrorInvalidScope)
+	})
+
+	t.Run("client without scope is granted none", func(t *testing.T) {
+		empty := oauth.OauthClient{}
+		scope, err := empty.ResolveScope("")
+		assert.NoError(t, err)
+		assert.Empty(t, scope)
+
+		_, err = empty.ResolveScope("user")
+		assert.EqualError(t, err, oauth.ErrorInvalidScope)
+	})
+}
+
+func TestOauthAccessTokenHasScope(t *testing.T) {
+	token := oauth.OauthAccessToken{Scope: null.StringFrom("user foo:read")}
+
+	assert.True(t, token.HasScope("foo:read"))
+	assert.False(t, token.HasScope("foo"))
+
+	empty := oauth.OauthAccessToken{}
+	assert.False(t, empty.HasScope("user"))
+}
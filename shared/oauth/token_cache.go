@@ -0,0 +1,277 @@
+package oauth
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-redis/redis"
+)
+
+const (
+	defaultTokenCacheSize       = 10000
+	defaultTokenCacheTTLSeconds = 60
+
+	cachedAccessTokenKeyFormat  = "oauth:access_token:%s"
+	cachedClientTokensKeyFormat = "oauth:client_tokens:%s"
+)
+
+// TokenCache is a read-through cache of access tokens in front of the
+// oauth_access_tokens table. Entries live for at most the cache TTL and never
+// past the Expires of their token. Revoking a token, or disabling its client,
+// must delete it from the cache.
+type TokenCache interface {
+	Delete(accessToken string)
+	DeleteByClientID(clientID string)
+	Get(accessToken string) (token OauthAccessToken, ok bool)
+	Set(token OauthAccessToken)
+	Stats() TokenCacheStats
+}
+
+// TokenCacheStats counts the lookups a TokenCache answered and the ones that
+// fell through to the database, since the process started.
+type TokenCacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// ProvideTokenCache is the provider for TokenCache. It uses Redis when a
+// client is available, sharing the cache and its invalidations between
+// instances, and falls back to an in-process LRU cache.
+func ProvideTokenCache(client *redis.Client, config *configs.Config) TokenCache {
+	ttl := config.Auth.OAuth.TokenCacheTTLSeconds
+	if ttl <= 0 {
+		ttl = defaultTokenCacheTTLSeconds
+	}
+
+	if client == nil {
+		size := config.Auth.OAuth.TokenCacheSize
+		if size <= 0 {
+			size = defaultTokenCacheSize
+		}
+		return NewTokenCacheLRU(size, time.Duration(ttl)*time.Second)
+	}
+
+	return NewTokenCacheRedis(client, time.Duration(ttl)*time.Second)
+}
+
+// cacheTTL bounds ttl by the time left until a token expires.
+func cacheTTL(token OauthAccessToken, ttl time.Duration) time.Duration {
+	if untilExpiry := time.Until(token.Expires); untilExpiry < ttl {
+		return untilExpiry
+	}
+
+	return ttl
+}
+
+type tokenCacheCounter struct {
+	hits   uint64
+	misses uint64
+}
+
+func (c *tokenCacheCounter) count(hit bool) {
+	if hit {
+		atomic.AddUint64(&c.hits, 1)
+		return
+	}
+	atomic.AddUint64(&c.misses, 1)
+}
+
+func (c *tokenCacheCounter) Stats() TokenCacheStats {
+	return TokenCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// TokenCacheLRU is an in-process TokenCache that evicts the least recently
+// used token once it holds size tokens. Invalidations only reach the instance
+// they happen on.
+type TokenCacheLRU struct {
+	tokenCacheCounter
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type tokenCacheEntry struct {
+	token     OauthAccessToken
+	expiresAt time.Time
+}
+
+// NewTokenCacheLRU creates a new TokenCacheLRU.
+func NewTokenCacheLRU(size int, ttl time.Duration) *TokenCacheLRU {
+	return &TokenCacheLRU{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Delete deletes a token from the cache.
+func (c *TokenCacheLRU) Delete(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[accessToken]; ok {
+		c.remove(element)
+	}
+}
+
+// DeleteByClientID deletes every token of a client from the cache.
+func (c *TokenCacheLRU) DeleteByClientID(clientID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*tokenCacheEntry).token.ClientID == clientID {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+// Get resolves a token from the cache.
+func (c *TokenCacheLRU) Get(accessToken string) (token OauthAccessToken, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer func() { c.count(ok) }()
+
+	element, found := c.entries[accessToken]
+	if !found {
+		return
+	}
+
+	entry := element.Value.(*tokenCacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.remove(element)
+		return
+	}
+
+	c.order.MoveToFront(element)
+	return entry.token, true
+}
+
+// Set stores a token in the cache, unless it is about to expire.
+func (c *TokenCacheLRU) Set(token OauthAccessToken) {
+	ttl := cacheTTL(token, c.ttl)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &tokenCacheEntry{token: token, expiresAt: time.Now().Add(ttl)}
+	if element, ok := c.entries[token.AccessToken]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[token.AccessToken] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *TokenCacheLRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*tokenCacheEntry).token.AccessToken)
+}
+
+// TokenCacheRedis is a TokenCache shared by every instance through Redis.
+// The tokens cached for each client are tracked in a set so that disabling a
+// client can delete them. Redis errors are logged and treated as misses.
+type TokenCacheRedis struct {
+	tokenCacheCounter
+	Client *redis.Client
+	ttl    time.Duration
+}
+
+// NewTokenCacheRedis creates a new TokenCacheRedis.
+func NewTokenCacheRedis(client *redis.Client, ttl time.Duration) *TokenCacheRedis {
+	return &TokenCacheRedis{Client: client, ttl: ttl}
+}
+
+// Delete deletes a token from the cache.
+func (c *TokenCacheRedis) Delete(accessToken string) {
+	err := c.Client.Del(fmt.Sprintf(cachedAccessTokenKeyFormat, accessToken)).Err()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// DeleteByClientID deletes every token of a client from the cache.
+func (c *TokenCacheRedis) DeleteByClientID(clientID string) {
+	setKey := fmt.Sprintf(cachedClientTokensKeyFormat, clientID)
+	accessTokens, err := c.Client.SMembers(setKey).Result()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	keys := []string{setKey}
+	for _, accessToken := range accessTokens {
+		keys = append(keys, fmt.Sprintf(cachedAccessTokenKeyFormat, accessToken))
+	}
+
+	err = c.Client.Del(keys...).Err()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// Get resolves a token from the cache.
+func (c *TokenCacheRedis) Get(accessToken string) (token OauthAccessToken, ok bool) {
+	defer func() { c.count(ok) }()
+
+	value, err := c.Client.Get(fmt.Sprintf(cachedAccessTokenKeyFormat, accessToken)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	err = json.Unmarshal(value, &token)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return token, true
+}
+
+// Set stores a token in the cache, unless it is about to expire.
+func (c *TokenCacheRedis) Set(token OauthAccessToken) {
+	ttl := cacheTTL(token, c.ttl)
+	if ttl <= 0 {
+		return
+	}
+
+	value, err := json.Marshal(token)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	setKey := fmt.Sprintf(cachedClientTokensKeyFormat, token.ClientID)
+	pipe := c.Client.TxPipeline()
+	pipe.Set(fmt.Sprintf(cachedAccessTokenKeyFormat, token.AccessToken), value, ttl)
+	pipe.SAdd(setKey, token.AccessToken)
+	pipe.Expire(setKey, c.ttl)
+	_, err = pipe.Exec()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
@@ -0,0 +1,84 @@
+package oauth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenCacheLRU(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	tokenA := oauth.OauthAccessToken{AccessToken: "a", ClientID: "client_web", Expires: expires}
+	tokenB := oauth.OauthAccessToken{AccessToken: "b", ClientID: "client_app", Expires: expires}
+	tokenC := oauth.OauthAccessToken{AccessToken: "c", ClientID: "client_web", Expires: expires}
+
+	t.Run("evicts the least recently used token", func(t *testing.T) {
+		cache := oauth.NewTokenCacheLRU(2, time.Minute)
+		cache.Set(tokenA)
+		cache.Set(tokenB)
+		_, ok := cache.Get("a")
+		assert.True(t, ok)
+
+		cache.Set(tokenC)
+		_, ok = cache.Get("b")
+		assert.False(t, ok)
+		_, ok = cache.Get("a")
+		assert.True(t, ok)
+
+		assert.Equal(t, oauth.TokenCacheStats{Hits: 2, Misses: 1}, cache.Stats())
+	})
+
+	t.Run("never outlives the token", func(t *testing.T) {
+		cache := oauth.NewTokenCacheLRU(2, time.Minute)
+		cache.Set(oauth.OauthAccessToken{AccessToken: "expired", Expires: time.Now().Add(-time.Second)})
+		_, ok := cache.Get("expired")
+		assert.False(t, ok)
+	})
+
+	t.Run("deletes tokens", func(t *testing.T) {
+		cache := oauth.NewTokenCacheLRU(3, time.Minute)
+		cache.Set(tokenA)
+		cache.Set(tokenB)
+		cache.Set(tokenC)
+
+		cache.Delete("b")
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+
+		cache.DeleteByClientID("client_web")
+		_, ok = cache.Get("a")
+		assert.False(t, ok)
+		_, ok = cache.Get("c")
+		assert.False(t, ok)
+	})
+}
+
+type userAuthenticatorStub struct {
+	oauth.UserAuthenticator
+	calls int
+}
+
+func (u *userAuthenticatorStub) ResolveRole(userID string) (string, error) {
+	u.calls++
+	return "student", nil
+}
+
+func TestParserReadsUserRoleFromCache(t *testing.T) {
+	cache := oauth.NewTokenCacheLRU(2, time.Minute)
+	cache.Set(oauth.OauthAccessToken{
+		AccessToken: "a",
+		ClientID:    "client_web",
+		UserID:      null.StringFrom("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Expires:     time.Now().Add(time.Hour),
+		UserRole:    "admin",
+	})
+	users := &userAuthenticatorStub{}
+
+	token, err := oauth.NewParser(oauth.TokenStore{}, cache, users).Parse("Bearer a")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", token.UserRole)
+	assert.Zero(t, users.calls)
+}
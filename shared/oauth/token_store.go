@@ -3,12 +3,17 @@ package oauth
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// TokenStore persists OAuth2 tokens, codes and clients. Writes and the
+// lookups of grants go to db; access token lookups, which run on every
+// request that misses the token cache, go to read.
 type TokenStore struct {
-	db *sqlx.DB
+	db   *sqlx.DB
+	read *sqlx.DB
 }
 
 const (
@@ -35,26 +40,94 @@ const (
 		FROM
 			oauth_access_tokens`
 
+	queryInsertRefreshToken = `INSERT INTO oauth_refresh_tokens (
+			refresh_token_hash,
+			client_id,
+			user_id,
+			expires,
+			scope
+		) VALUES (
+			:refresh_token_hash,
+			:client_id,
+			:user_id,
+			:expires,
+			:scope
+		)`
+
+	querySelectRefreshToken = `SELECT
+			refresh_token_hash,
+			client_id,
+			user_id,
+			expires,
+			scope
+		FROM
+			oauth_refresh_tokens`
+
+	queryDeleteRefreshToken = `DELETE FROM oauth_refresh_tokens WHERE refresh_token_hash = ?`
+
+	queryInsertAuthorizationCode = `INSERT INTO oauth_authorization_codes (
+			code,
+			client_id,
+			user_id,
+			redirect_uri,
+			scope,
+			code_challenge,
+			code_challenge_method,
+			expires
+		) VALUES (
+			:code,
+			:client_id,
+			:user_id,
+			:redirect_uri,
+			:scope,
+			:code_challenge,
+			:code_challenge_method,
+			:expires
+		)`
+
+	querySelectAuthorizationCode = `SELECT
+			code,
+			client_id,
+			user_id,
+			redirect_uri,
+			scope,
+			code_challenge,
+			code_challenge_method,
+			expires
+		FROM
+			oauth_authorization_codes`
+
+	queryDeleteAuthorizationCode = `DELETE FROM oauth_authorization_codes WHERE code = ?`
+
+	queryDeleteExpiredAccessTokens = `DELETE FROM oauth_access_tokens WHERE expires < ? LIMIT ?`
+
+	queryDeleteExpiredRefreshTokens = `DELETE FROM oauth_refresh_tokens WHERE expires < ? LIMIT ?`
+
+	queryDeleteExpiredAuthorizationCodes = `DELETE FROM oauth_authorization_codes WHERE expires < ? LIMIT ?`
+
+	queryDeleteClientAccessToken = `DELETE FROM oauth_access_tokens WHERE access_token = ? AND client_id = ?`
+
+	queryDeleteClientRefreshToken = `DELETE FROM oauth_refresh_tokens WHERE refresh_token_hash = ? AND client_id = ?`
+
+	queryUpdateClientSecret = `UPDATE oauth_clients SET client_secret = ? WHERE client_id = ? AND client_secret = ?`
+
 	querySelectClients = `SELECT
 			client_id,
 			client_secret,
+			previous_client_secret,
+			previous_secret_expires,
 			redirect_uri,
-			grant_types
+			grant_types,
+			scope,
+			disabled_at
 		FROM 
 			oauth_clients`
-
-	querySelectUser = `
-			SELECT
-				id,
-				username,
-				password
-			FROM
-				user`
 )
 
 func NewTokenStore(db *sqlx.DB) TokenStore {
 	return TokenStore{
-		db: db,
+		db:   db,
+		read: db,
 	}
 }
 
@@ -73,11 +146,194 @@ func (a *TokenStore) createAccessToken(accessToken OauthAccessToken) error {
 	return nil
 }
 
+// createAccessTokenWithRefreshToken stores an access token and the refresh
+// token issued with it in a single transaction.
+func (a *TokenStore) createAccessTokenWithRefreshToken(accessToken OauthAccessToken, refreshToken OauthRefreshToken) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	err = txCreateTokens(tx, accessToken, refreshToken)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rotateRefreshToken consumes the current refresh token and stores the access
+// token and refresh token that replace it in a single transaction. It fails
+// with ErrorInvalidRefreshToken when the current token was already consumed,
+// so a refresh token can only be used once even under concurrent requests.
+func (a *TokenStore) rotateRefreshToken(current OauthRefreshToken, accessToken OauthAccessToken, next OauthRefreshToken) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(queryDeleteRefreshToken, current.TokenHash)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if affected == 0 {
+		_ = tx.Rollback()
+		return errors.New(ErrorInvalidRefreshToken)
+	}
+
+	err = txCreateTokens(tx, accessToken, next)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (a *TokenStore) createAuthorizationCode(code OauthAuthorizationCode) error {
+	_, err := a.db.NamedExec(queryInsertAuthorizationCode, code)
+	return err
+}
+
+func (a *TokenStore) resolveAuthorizationCodeByCode(code string) (authorizationCode OauthAuthorizationCode, err error) {
+	err = a.db.Get(&authorizationCode, querySelectAuthorizationCode+" WHERE code = ?", code)
+	if err == sql.ErrNoRows {
+		err = errors.New(ErrorInvalidAuthorizationCode)
+	}
+
+	return
+}
+
+// exchangeAuthorizationCode consumes an authorization code and stores the
+// access token, and the refresh token if any, issued for it in a single
+// transaction. It fails with ErrorInvalidAuthorizationCode when the code was
+// already consumed, so a code can only be exchanged once.
+func (a *TokenStore) exchangeAuthorizationCode(code OauthAuthorizationCode, accessToken OauthAccessToken, refreshToken *OauthRefreshToken) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(queryDeleteAuthorizationCode, code.Code)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if affected == 0 {
+		_ = tx.Rollback()
+		return errors.New(ErrorInvalidAuthorizationCode)
+	}
+
+	if refreshToken != nil {
+		err = txCreateTokens(tx, accessToken, *refreshToken)
+	} else {
+		_, err = tx.NamedExec(queryInsertAccessToken, accessToken)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// resolveRefreshTokenByRefreshToken resolves a refresh token by its plaintext
+// value, which is only stored as a hash.
+func (a *TokenStore) resolveRefreshTokenByRefreshToken(refreshToken string) (oauthRefreshToken OauthRefreshToken, err error) {
+	err = a.db.Get(&oauthRefreshToken, querySelectRefreshToken+" WHERE refresh_token_hash = ?", hashRefreshToken(refreshToken))
+	switch {
+	case err == sql.ErrNoRows:
+		err = errors.New(ErrorInvalidRefreshToken)
+		return
+	case err != nil:
+		return
+	}
+
+	return
+}
+
+// deleteAccessToken deletes an access token of a client and reports whether
+// there was one.
+func (a *TokenStore) deleteAccessToken(accessToken string, clientID string) (bool, error) {
+	return a.delete(queryDeleteClientAccessToken, accessToken, clientID)
+}
+
+// deleteRefreshToken deletes a refresh token of a client and reports whether
+// there was one.
+func (a *TokenStore) deleteRefreshToken(refreshToken string, clientID string) (bool, error) {
+	return a.delete(queryDeleteClientRefreshToken, hashRefreshToken(refreshToken), clientID)
+}
+
+// deleteExpiredAccessTokens deletes at most limit access tokens that expired
+// before the given time and returns how many were deleted.
+func (a *TokenStore) deleteExpiredAccessTokens(before time.Time, limit int) (int64, error) {
+	return a.deleteCount(queryDeleteExpiredAccessTokens, before, limit)
+}
+
+// deleteExpiredRefreshTokens deletes at most limit refresh tokens that
+// expired before the given time and returns how many were deleted.
+func (a *TokenStore) deleteExpiredRefreshTokens(before time.Time, limit int) (int64, error) {
+	return a.deleteCount(queryDeleteExpiredRefreshTokens, before, limit)
+}
+
+// deleteExpiredAuthorizationCodes deletes at most limit authorization codes
+// that expired before the given time and returns how many were deleted.
+func (a *TokenStore) deleteExpiredAuthorizationCodes(before time.Time, limit int) (int64, error) {
+	return a.deleteCount(queryDeleteExpiredAuthorizationCodes, before, limit)
+}
+
+func (a *TokenStore) delete(query string, args ...interface{}) (bool, error) {
+	affected, err := a.deleteCount(query, args...)
+	return affected > 0, err
+}
+
+func (a *TokenStore) deleteCount(query string, args ...interface{}) (int64, error) {
+	result, err := a.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func txCreateTokens(tx *sqlx.Tx, accessToken OauthAccessToken, refreshToken OauthRefreshToken) error {
+	_, err := tx.NamedExec(queryInsertAccessToken, accessToken)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(queryInsertRefreshToken, refreshToken)
+	return err
+}
+
+// resolveAccessTokenByAccessToken resolves an access token from the read
+// connection. Tokens it does not know yet are looked up again on the write
+// connection, so tokens are usable before they reach a lagging replica.
 func (a *TokenStore) resolveAccessTokenByAccessToken(accessToken string) (oauthAccessToken OauthAccessToken, err error) {
-	err = a.db.Get(&oauthAccessToken, querySelectAccessToken+" WHERE access_token = ?", accessToken)
+	err = a.read.Get(&oauthAccessToken, querySelectAccessToken+" WHERE access_token = ?", accessToken)
+	if err == sql.ErrNoRows && a.read != a.db {
+		err = a.db.Get(&oauthAccessToken, querySelectAccessToken+" WHERE access_token = ?", accessToken)
+	}
+
 	switch {
 	case err == sql.ErrNoRows:
-		err = errors.New(ErrorClientNotFound)
+		err = errors.New(ErrorInvalidToken)
 		return
 	case err != nil:
 		return
@@ -110,16 +366,19 @@ func (a *TokenStore) resolveClientByClientID(clientID string) (client OauthClien
 	return
 }
 
-func (a *TokenStore) resolveByTelephoneOrEmail(username string) (User, error) {
-	var user User
+// rehashClientSecret replaces the SHA-256 hash of the current secret of a
+// client with a bcrypt hash, once the client has presented that secret. The
+// hash is left alone when the secret was rotated in the meantime.
+func (a *TokenStore) rehashClientSecret(client OauthClient, secret string) error {
+	if !verifyClientSecret(secret, client.ClientSecret) {
+		return nil
+	}
 
-	err := a.db.Get(&user, querySelectUser+" WHERE telephone = ? OR  email = ?", username, username)
-	switch {
-	case err == sql.ErrNoRows:
-		return User{}, errors.New(ErrorClientNotFound)
-	case err != nil:
-		return User{}, err
+	hash, err := HashClientSecret(secret)
+	if err != nil {
+		return err
 	}
 
-	return user, nil
+	_, err = a.db.Exec(queryUpdateClientSecret, hash, client.ClientID, client.ClientSecret)
+	return err
 }
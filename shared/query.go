@@ -0,0 +1,11 @@
+package shared
+
+import "strings"
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EscapeLike escapes the wildcard characters of a LIKE pattern, so that a
+// value matches literally.
+func EscapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
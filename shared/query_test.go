@@ -0,0 +1,13 @@
+package shared_test
+
+import (
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `50\%\_off\\`, shared.EscapeLike(`50%_off\`))
+	assert.Equal(t, "plain", shared.EscapeLike("plain"))
+}
@@ -0,0 +1,50 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B test secret, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	t.Run("rfcVectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+
+		for unix, expected := range vectors {
+			code, err := totp.GenerateCode(secret, totp.Step(time.Unix(unix, 0)))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, code)
+		}
+	})
+
+	t.Run("validateWithSkew", func(t *testing.T) {
+		now := time.Unix(1234567890, 0)
+		previous, err := totp.GenerateCode(secret, totp.Step(now)-1)
+		assert.NoError(t, err)
+
+		step, ok, err := totp.Validate(secret, previous, now, 1)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now)-1, step)
+
+		_, ok, err = totp.Validate(secret, previous, now, 0)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("provisioningURI", func(t *testing.T) {
+		uri := totp.ProvisioningURI("Evermos", "alice", "ABC")
+		assert.Equal(t, "otpauth://totp/Evermos:alice?algorithm=SHA1&digits=6&issuer=Evermos&period=30&secret=ABC", uri)
+	})
+}
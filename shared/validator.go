@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"regexp"
 	"sync"
 
 	"github.com/go-playground/validator/v10"
@@ -10,12 +11,22 @@ import (
 var once sync.Once
 var v *validator.Validate
 
+// usernamePattern allows 3 to 32 letters, digits, dots, underscores and
+// hyphens, starting with a letter or digit.
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,31}$`)
+
 // GetValidator is responsible for returning a single instance of the validator.
 func GetValidator() *validator.Validate {
 	once.Do(func() {
 		log.Info().Msg("Validator initialized.")
 		v = validator.New()
+		_ = v.RegisterValidation("username", validateUsername)
 	})
 
 	return v
 }
+
+// validateUsername validates the "username" tag.
+func validateUsername(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(fl.Field().String())
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/evermos/boilerplate-go/docs"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/evermos/boilerplate-go/transport/http/router"
 	"github.com/go-chi/chi"
@@ -39,19 +40,21 @@ const (
 
 // HTTP is the HTTP server.
 type HTTP struct {
-	Config *configs.Config
-	DB     *infras.MySQLConn
-	Router router.Router
-	State  ServerState
-	mux    *chi.Mux
+	Config  *configs.Config
+	DB      *infras.MySQLConn
+	Router  router.Router
+	Janitor *oauth.Janitor
+	State   ServerState
+	mux     *chi.Mux
 }
 
 // ProvideHTTP is the provider for HTTP.
-func ProvideHTTP(db *infras.MySQLConn, config *configs.Config, router router.Router) *HTTP {
+func ProvideHTTP(db *infras.MySQLConn, config *configs.Config, router router.Router, janitor *oauth.Janitor) *HTTP {
 	return &HTTP{
-		DB:     db,
-		Config: config,
-		Router: router,
+		DB:      db,
+		Config:  config,
+		Router:  router,
+		Janitor: janitor,
 	}
 }
 
@@ -62,6 +65,7 @@ func (h *HTTP) SetupAndServe() {
 	h.setupSwaggerDocs()
 	h.setupRoutes()
 	h.setupGracefulShutdown()
+	h.Janitor.Start()
 	h.State = ServerStateReady
 
 	h.logServerInfo()
@@ -102,6 +106,7 @@ func (h *HTTP) respondToSigterm(done chan os.Signal) {
 	shutdownConfig := h.Config.Server.Shutdown
 
 	log.Info().Msg("Received SIGTERM.")
+	h.Janitor.Stop()
 	log.Info().Int64("seconds", shutdownConfig.GracePeriodSeconds).Msg("Entering grace period.")
 	h.State = ServerStateInGracePeriod
 	time.Sleep(time.Duration(shutdownConfig.GracePeriodSeconds) * time.Second)
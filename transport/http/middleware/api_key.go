@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	HeaderAPIKey = "X-API-Key"
+)
+
+// APIKeyAuthenticator checks an API key presented by a caller and returns
+// the key's ID, the user who created it and the scopes it was granted.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (id uuid.UUID, createdBy uuid.UUID, scopes []string, err error)
+}
+
+// APIKeyAuthentication authenticates service-to-service calls carrying an
+// API key in the X-API-Key header.
+type APIKeyAuthentication struct {
+	APIKeyAuthenticator APIKeyAuthenticator
+}
+
+// ProvideAPIKeyAuthentication is the provider for APIKeyAuthentication.
+func ProvideAPIKeyAuthentication(apiKeyAuthenticator APIKeyAuthenticator) *APIKeyAuthentication {
+	return &APIKeyAuthentication{
+		APIKeyAuthenticator: apiKeyAuthenticator,
+	}
+}
+
+// Authenticate authenticates a request carrying an API key.
+func (a *APIKeyAuthentication) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(HeaderAPIKey)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	id, createdBy, scopes, err := a.APIKeyAuthenticator.AuthenticateAPIKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAPIKeyPrincipal(id, createdBy, scopes), nil
+}
+
+// Method returns AuthMethodAPIKey.
+func (a *APIKeyAuthentication) Method() AuthMethod {
+	return AuthMethodAPIKey
+}
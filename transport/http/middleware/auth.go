@@ -2,43 +2,59 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
-	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 )
 
 type Authentication struct {
-	db *infras.MySQLConn
+	token *oauth.Token
 }
 
 const (
 	HeaderAuthorization = "Authorization"
 )
 
-func ProvideAuthentication(db *infras.MySQLConn) *Authentication {
+// ProvideAuthentication is the provider for Authentication. Access tokens
+// are resolved through the cache of token.
+func ProvideAuthentication(token *oauth.Token) *Authentication {
 	return &Authentication{
-		db: db,
+		token: token,
 	}
 }
 
+// Authenticate authenticates a request carrying an opaque OAuth bearer
+// token. JWTs are left to other authenticators.
+func (a *Authentication) Authenticate(r *http.Request) (*Principal, error) {
+	accessToken := r.Header.Get(HeaderAuthorization)
+	if !strings.HasPrefix(accessToken, string(oauth.Bearer)+" ") || strings.Count(accessToken, ".") == 2 {
+		return nil, ErrNoCredentials
+	}
+
+	parseToken, err := a.parse(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOAuthPrincipal(parseToken), nil
+}
+
+// Method returns AuthMethodOAuth.
+func (a *Authentication) Method() AuthMethod {
+	return AuthMethodOAuth
+}
+
 func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accessToken := r.Header.Get(HeaderAuthorization)
-		token := oauth.New(a.db.Read, oauth.Config{})
-
-		parseToken, err := token.ParseWithAccessToken(accessToken)
+		parseToken, err := a.parse(r.Header.Get(HeaderAuthorization))
 		if err != nil {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-
-		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+			response.WithError(w, err)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withOAuthPrincipal(r, parseToken))
 	})
 }
 
@@ -49,39 +65,45 @@ func (a *Authentication) ClientCredentialWithQueryParameter(next http.Handler) h
 		tokenType := params.Get("token_type")
 		accessToken := tokenType + " " + token
 
-		auth := oauth.New(a.db.Read, oauth.Config{})
-		parseToken, err := auth.ParseWithAccessToken(accessToken)
+		parseToken, err := a.parse(accessToken)
 		if err != nil {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-
-		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+			response.WithError(w, err)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withOAuthPrincipal(r, parseToken))
 	})
 }
 
 func (a *Authentication) Password(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accessToken := r.Header.Get(HeaderAuthorization)
-		token := oauth.New(a.db.Read, oauth.Config{})
-
-		parseToken, err := token.ParseWithAccessToken(accessToken)
+		parseToken, err := a.parse(r.Header.Get(HeaderAuthorization))
 		if err != nil {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+			response.WithError(w, err)
 			return
 		}
 
-		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+		if !parseToken.VerifyUserLoggedIn() {
+			response.WithMessage(w, http.StatusUnauthorized, oauth.ErrorInvalidPassword)
 			return
 		}
 
-		if !parseToken.VerifyUserLoggedIn() {
+		next.ServeHTTP(w, withOAuthPrincipal(r, parseToken))
+	})
+}
+
+// RequireUserToken applies the Password rule to principals authenticated by
+// a chain: OAuth tokens must have been issued to a user. Other principals are
+// let through. It must run after an authentication middleware.
+func (a *Authentication) RequireUserToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			response.WithError(w, failure.Unauthorized("missing credentials"))
+			return
+		}
+
+		if principal.Method == AuthMethodOAuth && !principal.UserID.Valid {
 			response.WithMessage(w, http.StatusUnauthorized, oauth.ErrorInvalidPassword)
 			return
 		}
@@ -89,3 +111,52 @@ func (a *Authentication) Password(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireScopes only lets requests through whose principal was granted every
+// one of scopes. Users signed in with a JWT are not limited by scopes; their
+// roles are checked by Authorization instead. It must run after an
+// authentication middleware.
+func (a *Authentication) RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				response.WithError(w, failure.Unauthorized("missing credentials"))
+				return
+			}
+
+			if !principal.ScopeLimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !principal.HasScope(scope) {
+					response.WithError(w, failure.Forbidden("missing scope "+scope))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parse resolves an "Authorization: Bearer" access token and checks that it
+// has not expired.
+func (a *Authentication) parse(accessToken string) (parseToken oauth.OauthAccessToken, err error) {
+	parseToken, err = a.token.ParseWithAccessToken(accessToken)
+	if err != nil {
+		return parseToken, failure.Unauthorized(err.Error())
+	}
+
+	if !parseToken.VerifyExpireIn() {
+		return parseToken, failure.Unauthorized(oauth.ErrorInvalidToken)
+	}
+
+	return
+}
+
+func withOAuthPrincipal(r *http.Request, token oauth.OauthAccessToken) *http.Request {
+	return r.WithContext(WithPrincipal(r.Context(), NewOAuthPrincipal(token)))
+}
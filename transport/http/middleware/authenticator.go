@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+)
+
+// ErrNoCredentials is returned by an Authenticator when a request carries no
+// credentials it understands, letting the next Authenticator of a chain try.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator is one way of authenticating requests.
+type Authenticator interface {
+	Authenticate(r *http.Request) (principal *Principal, err error)
+	Method() AuthMethod
+}
+
+// AuthenticatorChain tries its Authenticators in order and stores the
+// Principal of the first one that recognizes the request's credentials.
+// Invalid credentials are rejected right away rather than passed on.
+type AuthenticatorChain struct {
+	authenticators []Authenticator
+}
+
+// ProvideAuthenticatorChain is the provider for AuthenticatorChain. The
+// methods it tries, and their order, come from AUTH.AUTHENTICATORS and
+// default to every available method. Unknown methods are an error.
+func ProvideAuthenticatorChain(config *configs.Config, jwtAuthentication *JWTAuthentication, authentication *Authentication, apiKeyAuthentication *APIKeyAuthentication) (*AuthenticatorChain, error) {
+	available := map[AuthMethod]Authenticator{
+		AuthMethodJWT:    jwtAuthentication,
+		AuthMethodOAuth:  authentication,
+		AuthMethodAPIKey: apiKeyAuthentication,
+	}
+
+	methods := config.Auth.Authenticators
+	if len(methods) == 0 {
+		methods = []string{string(AuthMethodJWT), string(AuthMethodOAuth), string(AuthMethodAPIKey)}
+	}
+
+	authenticators := make([]Authenticator, 0, len(methods))
+	for _, method := range methods {
+		authenticator, ok := available[AuthMethod(method)]
+		if !ok {
+			return nil, fmt.Errorf("unknown authenticator %q in AUTH.AUTHENTICATORS", method)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+
+	return NewAuthenticatorChain(authenticators...), nil
+}
+
+// NewAuthenticatorChain creates an AuthenticatorChain trying authenticators
+// in the given order.
+func NewAuthenticatorChain(authenticators ...Authenticator) *AuthenticatorChain {
+	return &AuthenticatorChain{authenticators: authenticators}
+}
+
+// Only returns a chain that only tries the given methods, keeping their
+// configured order. It is meant for routes that cannot serve every kind of
+// principal.
+func (c *AuthenticatorChain) Only(methods ...AuthMethod) *AuthenticatorChain {
+	authenticators := make([]Authenticator, 0, len(methods))
+	for _, authenticator := range c.authenticators {
+		for _, method := range methods {
+			if authenticator.Method() == method {
+				authenticators = append(authenticators, authenticator)
+				break
+			}
+		}
+	}
+
+	return NewAuthenticatorChain(authenticators...)
+}
+
+// Authenticate is the middleware that authenticates requests with the chain
+// and stores their Principal in the request context.
+func (c *AuthenticatorChain) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, authenticator := range c.authenticators {
+			principal, err := authenticator.Authenticate(r)
+			if err == ErrNoCredentials {
+				continue
+			}
+
+			if err != nil {
+				response.WithError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+			return
+		}
+
+		response.WithError(w, failure.Unauthorized("missing credentials"))
+	})
+}
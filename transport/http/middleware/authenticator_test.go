@@ -0,0 +1,153 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+type authenticatorFunc struct {
+	method       middleware.AuthMethod
+	authenticate func(r *http.Request) (*middleware.Principal, error)
+}
+
+func (a authenticatorFunc) Authenticate(r *http.Request) (*middleware.Principal, error) {
+	return a.authenticate(r)
+}
+
+func (a authenticatorFunc) Method() middleware.AuthMethod {
+	return a.method
+}
+
+func TestAuthenticatorChain(t *testing.T) {
+	headerAuthenticator := func(method middleware.AuthMethod, header string) authenticatorFunc {
+		return authenticatorFunc{method, func(r *http.Request) (*middleware.Principal, error) {
+			switch r.Header.Get(header) {
+			case "":
+				return nil, middleware.ErrNoCredentials
+			case "valid":
+				return &middleware.Principal{Subject: header, Method: method}, nil
+			default:
+				return nil, failure.Unauthorized("invalid " + header)
+			}
+		}}
+	}
+
+	chain := middleware.NewAuthenticatorChain(
+		headerAuthenticator(middleware.AuthMethodJWT, "X-First"),
+		headerAuthenticator(middleware.AuthMethodOAuth, "X-Second"),
+	)
+
+	serve := func(chain *middleware.AuthenticatorChain, headers map[string]string) (int, *middleware.Principal) {
+		var principal *middleware.Principal
+		handler := chain.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ = middleware.PrincipalFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code, principal
+	}
+
+	t.Run("uses the first authenticator that recognizes the request", func(t *testing.T) {
+		code, principal := serve(chain, map[string]string{"X-Second": "valid"})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, middleware.AuthMethodOAuth, principal.Method)
+
+		_, principal = serve(chain, map[string]string{"X-First": "valid", "X-Second": "valid"})
+		assert.Equal(t, middleware.AuthMethodJWT, principal.Method)
+	})
+
+	t.Run("rejects invalid credentials without trying further", func(t *testing.T) {
+		code, principal := serve(chain, map[string]string{"X-First": "forged", "X-Second": "valid"})
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Nil(t, principal)
+	})
+
+	t.Run("rejects requests without credentials", func(t *testing.T) {
+		code, _ := serve(chain, nil)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("only tries the selected methods", func(t *testing.T) {
+		code, _ := serve(chain.Only(middleware.AuthMethodJWT), map[string]string{"X-Second": "valid"})
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
+
+func TestPrincipalActorID(t *testing.T) {
+	userID, _ := uuid.NewV4()
+
+	id, ok := middleware.NewJWTPrincipal(&jwtmodel.Claims{UserId: userID}).ActorID()
+	assert.True(t, ok)
+	assert.Equal(t, userID, id)
+
+	_, ok = middleware.NewOAuthPrincipal(oauth.OauthAccessToken{ClientID: "batch"}).ActorID()
+	assert.False(t, ok)
+
+	keyID, _ := uuid.NewV4()
+	issuerID, _ := uuid.NewV4()
+	id, ok = middleware.NewAPIKeyPrincipal(keyID, issuerID, nil).ActorID()
+	assert.True(t, ok)
+	assert.Equal(t, issuerID, id)
+
+	_, ok = middleware.ActorIDFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestRequireUserToken(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	handler := (&middleware.Authentication{}).RequireUserToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, test := range []struct {
+		name      string
+		principal *middleware.Principal
+		code      int
+	}{
+		{"jwt", middleware.NewJWTPrincipal(&jwtmodel.Claims{UserId: userID}), http.StatusOK},
+		{"oauth user", middleware.NewOAuthPrincipal(oauth.OauthAccessToken{ClientID: "web", UserID: null.StringFrom(userID.String())}), http.StatusOK},
+		{"oauth client", middleware.NewOAuthPrincipal(oauth.OauthAccessToken{ClientID: "batch"}), http.StatusUnauthorized},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req.WithContext(middleware.WithPrincipal(req.Context(), test.principal)))
+			assert.Equal(t, test.code, rec.Code)
+		})
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	handler := (&middleware.Authentication{}).RequireScopes("foo:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, test := range []struct {
+		name      string
+		principal *middleware.Principal
+		code      int
+	}{
+		{"jwt", middleware.NewJWTPrincipal(&jwtmodel.Claims{UserId: userID}), http.StatusOK},
+		{"oauth with scope", middleware.NewOAuthPrincipal(oauth.OauthAccessToken{ClientID: "web", Scope: null.StringFrom("foo:read foo:write")}), http.StatusOK},
+		{"oauth without scope", middleware.NewOAuthPrincipal(oauth.OauthAccessToken{ClientID: "web", Scope: null.StringFrom("foo:read")}), http.StatusForbidden},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req.WithContext(middleware.WithPrincipal(req.Context(), test.principal)))
+			assert.Equal(t, test.code, rec.Code)
+		})
+	}
+}
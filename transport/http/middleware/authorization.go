@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+)
+
+// PermissionAll grants every permission to a role.
+const PermissionAll = "*"
+
+// Authorization enforces role and permission requirements on routes. It must
+// be used after a middleware that stores a Principal in the request context.
+type Authorization struct {
+	rolePermissions map[string]map[string]bool
+}
+
+// ProvideAuthorization is the provider for Authorization, loading the
+// role to permission mapping from config.
+func ProvideAuthorization(config *configs.Config) *Authorization {
+	return NewAuthorization(config.Auth.RBAC.RolePermissions)
+}
+
+// NewAuthorization creates an Authorization from a role to permissions mapping.
+func NewAuthorization(rolePermissions map[string][]string) *Authorization {
+	a := &Authorization{
+		rolePermissions: make(map[string]map[string]bool),
+	}
+
+	for role, permissions := range rolePermissions {
+		role = strings.ToLower(role)
+		a.rolePermissions[role] = make(map[string]bool)
+		for _, permission := range permissions {
+			a.rolePermissions[role][strings.TrimSpace(permission)] = true
+		}
+	}
+
+	return a
+}
+
+// HasPermission checks whether a role is granted a permission.
+func (a *Authorization) HasPermission(role string, permission string) bool {
+	permissions := a.rolePermissions[strings.ToLower(role)]
+	return permissions[PermissionAll] || permissions[permission]
+}
+
+// principalHasPermission checks whether a principal is granted a
+// permission. Users signed in with a JWT need it through one of their roles.
+// Scope-limited principals need it as a scope, and those acting for a user
+// also through the user's role.
+func (a *Authorization) principalHasPermission(principal *Principal, permission string) bool {
+	if !principal.ScopeLimited() {
+		return a.rolesHavePermission(principal.Roles, permission)
+	}
+
+	if !principal.HasScope(permission) {
+		return false
+	}
+
+	if principal.UserID.Valid {
+		return a.rolesHavePermission(principal.Roles, permission)
+	}
+
+	return true
+}
+
+// rolesHavePermission checks whether one of roles is granted a permission.
+func (a *Authorization) rolesHavePermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		if a.HasPermission(role, permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireRole only lets requests through whose principal has one of roles.
+func (a *Authorization) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				response.WithError(w, failure.Unauthorized("missing credentials"))
+				return
+			}
+
+			for _, role := range roles {
+				if principal.HasRole(role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			response.WithError(w, failure.Forbidden("role is not allowed to access this resource"))
+		})
+	}
+}
+
+// RequirePermission only lets requests through whose principal is granted
+// every one of permissions, as described by principalHasPermission.
+func (a *Authorization) RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				response.WithError(w, failure.Unauthorized("missing credentials"))
+				return
+			}
+
+			for _, permission := range permissions {
+				if !a.principalHasPermission(principal, permission) {
+					response.WithError(w, failure.Forbidden("missing permission "+permission))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorization(t *testing.T) {
+	authorization := middleware.NewAuthorization(map[string][]string{
+		"admin":   {middleware.PermissionAll},
+		"teacher": {"foo:read", "foo:write"},
+		"student": {"foo:read"},
+	})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(handler http.Handler, claims *jwtmodel.Claims) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if claims != nil {
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), middleware.NewJWTPrincipal(claims)))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("requirePermission", func(t *testing.T) {
+		handler := authorization.RequirePermission("foo:write")(ok)
+
+		assert.Equal(t, http.StatusOK, serve(handler, &jwtmodel.Claims{Role: "admin"}))
+		assert.Equal(t, http.StatusOK, serve(handler, &jwtmodel.Claims{Role: "teacher"}))
+		assert.Equal(t, http.StatusForbidden, serve(handler, &jwtmodel.Claims{Role: "student"}))
+		assert.Equal(t, http.StatusForbidden, serve(handler, &jwtmodel.Claims{Role: "unknown"}))
+		assert.Equal(t, http.StatusUnauthorized, serve(handler, nil))
+	})
+
+	t.Run("requirePermission accepts scopes", func(t *testing.T) {
+		handler := authorization.RequirePermission("foo:write")(ok)
+
+		serveScopes := func(scopes ...string) int {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{Scopes: scopes}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusOK, serveScopes("foo:read", "foo:write"))
+		assert.Equal(t, http.StatusForbidden, serveScopes("foo:read"))
+	})
+
+	t.Run("requirePermission needs the role and the scope of user tokens", func(t *testing.T) {
+		handler := authorization.RequirePermission("foo:write")(ok)
+
+		serveUser := func(role string, scopes ...string) int {
+			userID, _ := uuid.NewV4()
+			principal := &middleware.Principal{
+				UserID: uuid.NullUUID{UUID: userID, Valid: true},
+				Roles:  []string{role},
+				Scopes: scopes,
+				Method: middleware.AuthMethodOAuth,
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusOK, serveUser("teacher", "foo:write"))
+		assert.Equal(t, http.StatusForbidden, serveUser("student", "foo:write"))
+		assert.Equal(t, http.StatusForbidden, serveUser("teacher", "foo:read"))
+	})
+
+	t.Run("requireRole", func(t *testing.T) {
+		handler := authorization.RequireRole("admin")(ok)
+
+		assert.Equal(t, http.StatusOK, serve(handler, &jwtmodel.Claims{Role: "admin"}))
+		assert.Equal(t, http.StatusForbidden, serve(handler, &jwtmodel.Claims{Role: "teacher"}))
+	})
+}
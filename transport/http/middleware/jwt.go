@@ -1,40 +1,47 @@
 package middleware
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/jwtmodel"
 	"github.com/evermos/boilerplate-go/transport/http/response"
-	"github.com/golang-jwt/jwt"
 )
 
+// ClaimsVerifier checks that the claims of a validly signed JWT are still
+// honoured, for instance that the token has not been revoked.
+type ClaimsVerifier interface {
+	VerifyClaims(claims *jwtmodel.Claims) (err error)
+}
 
+// ClaimsKey is the context key under which the claims of a JWT are stored,
+// as ClaimsKey("claims"). ClaimsFromContext is the preferred way to read them.
+type ClaimsKey string
 
 type JWTAuthentication struct {
-	Config *configs.Config
+	Config         *configs.Config
+	KeySet         *jwtmodel.KeySet
+	ClaimsVerifier ClaimsVerifier
 }
 
-type ClaimsKey string
-
 const (
 	HeaderJWTAuthorization = "Authorization"
 )
 
-func ProvideJWTAuthentication(config *configs.Config) *JWTAuthentication {
-	return &JWTAuthentication{config}
+func ProvideJWTAuthentication(config *configs.Config, keySet *jwtmodel.KeySet, claimsVerifier ClaimsVerifier) *JWTAuthentication {
+	return &JWTAuthentication{
+		Config:         config,
+		KeySet:         keySet,
+		ClaimsVerifier: claimsVerifier,
+	}
 }
 
-
-func (a *JWTAuthentication) ValidateJWT(tokenString string) (*jwtmodel.Claims, error)  {
-	secret := a.Config.App.JWTSecret
-
-	token, err := jwt.ParseWithClaims(tokenString, &jwtmodel.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+func (a *JWTAuthentication) ValidateJWT(tokenString string) (*jwtmodel.Claims, error) {
+	token, err := a.KeySet.ParseWithClaims(tokenString, &jwtmodel.Claims{})
 
 	if err != nil {
 		return nil, fmt.Errorf("JWT validation failed: %v", err)
@@ -47,26 +54,49 @@ func (a *JWTAuthentication) ValidateJWT(tokenString string) (*jwtmodel.Claims, e
 	return nil, fmt.Errorf("JWT is not valid")
 }
 
+// Authenticate authenticates a request carrying a JWT bearer token. Bearer
+// tokens that are not JWTs, such as opaque OAuth tokens, are left to other
+// authenticators.
+func (a *JWTAuthentication) Authenticate(r *http.Request) (*Principal, error) {
+	tokenString := strings.TrimPrefix(r.Header.Get(HeaderJWTAuthorization), "Bearer ")
+	if strings.Count(tokenString, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.ValidateJWT(tokenString)
+	if err != nil {
+		log.Println(err)
+		return nil, failure.Unauthorized("Unauthorized")
+	}
+
+	err = a.ClaimsVerifier.VerifyClaims(claims)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return NewJWTPrincipal(claims), nil
+}
+
+// Method returns AuthMethodJWT.
+func (a *JWTAuthentication) Method() AuthMethod {
+	return AuthMethodJWT
+}
 
 func (a *JWTAuthentication) JWTMiddlewareValidate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")[(len("Bearer ")):]
-		if tokenString == "" {
+		principal, err := a.Authenticate(r)
+		if err == ErrNoCredentials {
 			log.Println("no header")
 			response.WithJSON(w, http.StatusUnauthorized, "Unauthorized")
 			return
-
 		}
 
-		claims, err := a.ValidateJWT(tokenString)
 		if err != nil {
-			log.Println(err)
-			response.WithJSON(w, http.StatusUnauthorized, "Unauthorized")
+			response.WithError(w, err)
 			return
 		}
 
-
-		ctx := context.WithValue(r.Context(), ClaimsKey("claims"), claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+)
+
+// AuthMethod names the way a Principal was authenticated.
+type AuthMethod string
+
+const (
+	AuthMethodJWT    AuthMethod = "jwt"
+	AuthMethodOAuth  AuthMethod = "oauth"
+	AuthMethodAPIKey AuthMethod = "api_key"
+)
+
+// PrincipalKey is the context key under which the authentication middlewares
+// store the Principal of a request.
+type PrincipalKey string
+
+// Principal is the authenticated identity behind a request, whichever way it
+// was authenticated. Subject is the user ID when the request acts for a user,
+// the API key ID for API keys and the client ID otherwise. IssuedBy is the
+// user who created an API key. Claims is only set for JWT principals.
+type Principal struct {
+	Subject  string
+	UserID   uuid.NullUUID
+	ClientID string
+	APIKeyID uuid.NullUUID
+	IssuedBy uuid.NullUUID
+	Roles    []string
+	Scopes   []string
+	Method   AuthMethod
+	Claims   *jwtmodel.Claims
+}
+
+// NewJWTPrincipal creates the Principal of a user signed in with a JWT.
+func NewJWTPrincipal(claims *jwtmodel.Claims) *Principal {
+	return &Principal{
+		Subject: claims.UserId.String(),
+		UserID:  uuid.NullUUID{UUID: claims.UserId, Valid: true},
+		Roles:   []string{claims.Role},
+		Method:  AuthMethodJWT,
+		Claims:  claims,
+	}
+}
+
+// NewOAuthPrincipal creates the Principal of an OAuth access token, which
+// acts for a user, with the user's role, when the token was issued to one.
+func NewOAuthPrincipal(token oauth.OauthAccessToken) *Principal {
+	principal := &Principal{
+		Subject:  token.ClientID,
+		ClientID: token.ClientID,
+		Scopes:   oauth.ParseScope(token.Scope.String),
+		Method:   AuthMethodOAuth,
+	}
+
+	if userID, err := uuid.FromString(token.UserID.String); token.UserID.Valid && err == nil {
+		principal.Subject = userID.String()
+		principal.UserID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	if principal.UserID.Valid && token.UserRole != "" {
+		principal.Roles = []string{token.UserRole}
+	}
+
+	return principal
+}
+
+// ActorID returns the user ID recorded in audit fields such as CreatedBy for
+// writes made by the principal: the user it acts for, or the user who created
+// the API key. Principals that do not act for a user, like OAuth client
+// credential tokens, have no actor ID.
+func (p *Principal) ActorID() (id uuid.UUID, ok bool) {
+	if p.UserID.Valid {
+		return p.UserID.UUID, true
+	}
+
+	if p.IssuedBy.Valid {
+		return p.IssuedBy.UUID, true
+	}
+
+	return uuid.Nil, false
+}
+
+// NewAPIKeyPrincipal creates the Principal of a service calling with the API
+// key id, which createdBy issued with scopes.
+func NewAPIKeyPrincipal(id uuid.UUID, createdBy uuid.UUID, scopes []string) *Principal {
+	return &Principal{
+		Subject:  id.String(),
+		APIKeyID: uuid.NullUUID{UUID: id, Valid: true},
+		IssuedBy: uuid.NullUUID{UUID: createdBy, Valid: createdBy != uuid.Nil},
+		Scopes:   scopes,
+		Method:   AuthMethodAPIKey,
+	}
+}
+
+// HasRole checks whether role is one of the roles of the principal.
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScopeLimited checks whether the principal is limited to the scopes it was
+// granted. Only users signed in with a JWT are not.
+func (p *Principal) ScopeLimited() bool {
+	return p.Method != AuthMethodJWT
+}
+
+// HasScope checks whether scope was granted to the principal.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithPrincipal returns a copy of ctx carrying principal, and the claims of
+// JWT principals under ClaimsKey.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	if principal != nil && principal.Claims != nil {
+		ctx = context.WithValue(ctx, ClaimsKey("claims"), principal.Claims)
+	}
+
+	return context.WithValue(ctx, PrincipalKey("principal"), principal)
+}
+
+// PrincipalFromContext returns the Principal stored by the authentication
+// middlewares.
+func PrincipalFromContext(ctx context.Context) (principal *Principal, ok bool) {
+	principal, ok = ctx.Value(PrincipalKey("principal")).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// ClaimsFromContext returns the JWT claims of a request authenticated with a
+// JWT.
+func ClaimsFromContext(ctx context.Context) (claims *jwtmodel.Claims, ok bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Claims == nil {
+		return nil, false
+	}
+
+	return principal.Claims, true
+}
+
+// ActorIDFromContext returns the actor ID of the Principal stored by the
+// authentication middlewares.
+func ActorIDFromContext(ctx context.Context) (id uuid.UUID, ok bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	return principal.ActorID()
+}
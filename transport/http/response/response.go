@@ -10,9 +10,18 @@ import (
 
 // Base is the base object of all responses
 type Base struct {
-	Data    *interface{} `json:"data,omitempty"`
-	Error   *string      `json:"error,omitempty"`
-	Message *string      `json:"message,omitempty"`
+	Data       *interface{} `json:"data,omitempty"`
+	Error      *string      `json:"error,omitempty"`
+	Message    *string      `json:"message,omitempty"`
+	Pagination *Pagination  `json:"pagination,omitempty"`
+}
+
+// Pagination is the metadata of a cursor-paginated list. NextCursor is
+// passed back to fetch the next page and is empty on the last page.
+type Pagination struct {
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	PageSize   int    `json:"pageSize"`
 }
 
 // NoContent sends a response without any content
@@ -30,6 +39,11 @@ func WithJSON(w http.ResponseWriter, code int, jsonPayload interface{}) {
 	respond(w, code, Base{Data: &jsonPayload})
 }
 
+// WithPaginatedJSON sends a response containing one page of a list
+func WithPaginatedJSON(w http.ResponseWriter, code int, jsonPayload interface{}, pagination Pagination) {
+	respond(w, code, Base{Data: &jsonPayload, Pagination: &pagination})
+}
+
 // WithError sends a response with an error message
 func WithError(w http.ResponseWriter, err error) {
 	code := failure.GetCode(err)
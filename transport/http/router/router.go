@@ -7,8 +7,13 @@ import (
 
 // DomainHandlers is a struct that contains all domain-specific handlers.
 type DomainHandlers struct {
-	FooBarBazHandler handlers.FooBarBazHandler
-	UserHandler   	 handlers.UserHandler
+	AdminAPIKeyHandler      handlers.AdminAPIKeyHandler
+	AdminOAuthClientHandler handlers.AdminOAuthClientHandler
+	AdminUserHandler        handlers.AdminUserHandler
+	FooBarBazHandler        handlers.FooBarBazHandler
+	JWKSHandler             handlers.JWKSHandler
+	OAuthHandler            handlers.OAuthHandler
+	UserHandler             handlers.UserHandler
 }
 
 // Router is the router struct containing handlers.
@@ -25,8 +30,14 @@ func ProvideRouter(domainHandlers DomainHandlers) Router {
 
 // SetupRoutes sets up all routing for this server.
 func (r *Router) SetupRoutes(mux *chi.Mux) {
+	r.DomainHandlers.JWKSHandler.Router(mux)
+	r.DomainHandlers.OAuthHandler.Router(mux)
+
 	mux.Route("/v1", func(rc chi.Router) {
 		r.DomainHandlers.FooBarBazHandler.Router(rc)
 		r.DomainHandlers.UserHandler.Router(rc)
+		r.DomainHandlers.AdminUserHandler.Router(rc)
+		r.DomainHandlers.AdminOAuthClientHandler.Router(rc)
+		r.DomainHandlers.AdminAPIKeyHandler.Router(rc)
 	})
 }
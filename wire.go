@@ -8,9 +8,14 @@ import (
 	// fooBarBazEvent "github.com/evermos/boilerplate-go/event/domain/foobarbaz"
 	"github.com/evermos/boilerplate-go/event/producer"
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/apikey"
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
+	"github.com/evermos/boilerplate-go/internal/domain/oauthclient"
 	"github.com/evermos/boilerplate-go/internal/domain/user"
 	"github.com/evermos/boilerplate-go/internal/handlers"
+	"github.com/evermos/boilerplate-go/shared/jwtmodel"
+	"github.com/evermos/boilerplate-go/shared/notifier"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http"
 	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/router"
@@ -25,6 +30,7 @@ var configurations = wire.NewSet(
 // Wiring for persistences.
 var persistences = wire.NewSet(
 	infras.ProvideMySQLConn,
+	infras.ProvideRedisClient,
 )
 
 // Wiring for domain FooBarBaz.
@@ -43,27 +49,88 @@ var domainFooBarBaz = wire.NewSet(
 var domainUser = wire.NewSet(
 	user.ProvideUserServiceImpl,
 	wire.Bind(new(user.UserService), new(*user.UserServiceImpl)),
+	wire.Bind(new(middleware.ClaimsVerifier), new(*user.UserServiceImpl)),
 
 	user.ProvideUserRepositoryMySQL,
 	wire.Bind(new(user.UserRepository), new(*user.UserRepositoryMySQL)),
-	
+
+	user.ProvideRefreshTokenRepositoryMySQL,
+	wire.Bind(new(user.RefreshTokenRepository), new(*user.RefreshTokenRepositoryMySQL)),
+	user.ProvideSessionRepositoryMySQL,
+	wire.Bind(new(user.SessionRepository), new(*user.SessionRepositoryMySQL)),
+
+	user.ProvidePasswordResetRepositoryMySQL,
+	wire.Bind(new(user.PasswordResetRepository), new(*user.PasswordResetRepositoryMySQL)),
+
+	user.ProvideEmailVerificationRepositoryMySQL,
+	wire.Bind(new(user.EmailVerificationRepository), new(*user.EmailVerificationRepositoryMySQL)),
+
+	user.ProvideTwoFactorRepositoryMySQL,
+	wire.Bind(new(user.TwoFactorRepository), new(*user.TwoFactorRepositoryMySQL)),
+	user.ProvideTwoFactorCipher,
+
+	user.ProvideOAuthUserAuthenticator,
+	wire.Bind(new(oauth.UserAuthenticator), new(*user.OAuthUserAuthenticator)),
+
+	user.ProvideTokenRevocationStore,
+	user.ProvideLoginAttemptStore,
+	user.ProvideLoginLimiter,
+	user.ProvidePasswordHasher,
+	user.ProvidePasswordPolicy,
+	jwtmodel.ProvideKeySet,
+	notifier.ProvideNotifier,
+)
+
+// Wiring for the OAuth2 server.
+var oauthServer = wire.NewSet(
+	oauth.ProvideToken,
+	oauth.ProvideTokenCache,
+	oauth.ProvideJanitor,
+)
+
+// Wiring for domain OAuthClient.
+var domainOAuthClient = wire.NewSet(
+	oauthclient.ProvideClientServiceImpl,
+	wire.Bind(new(oauthclient.ClientService), new(*oauthclient.ClientServiceImpl)),
+	oauthclient.ProvideClientRepositoryMySQL,
+	wire.Bind(new(oauthclient.ClientRepository), new(*oauthclient.ClientRepositoryMySQL)),
+)
+
+// Wiring for domain APIKey.
+var domainAPIKey = wire.NewSet(
+	apikey.ProvideAPIKeyServiceImpl,
+	wire.Bind(new(apikey.APIKeyService), new(*apikey.APIKeyServiceImpl)),
+	wire.Bind(new(middleware.APIKeyAuthenticator), new(*apikey.APIKeyServiceImpl)),
+	apikey.ProvideAPIKeyRepositoryMySQL,
+	wire.Bind(new(apikey.APIKeyRepository), new(*apikey.APIKeyRepositoryMySQL)),
 )
 
 // Wiring for all domains.
 var domains = wire.NewSet(
 	domainFooBarBaz,
 	domainUser,
+	domainOAuthClient,
+	domainAPIKey,
+	oauthServer,
 )
 
 var authMiddleware = wire.NewSet(
 	middleware.ProvideAuthentication,
 	middleware.ProvideJWTAuthentication,
+	middleware.ProvideAuthorization,
+	middleware.ProvideAPIKeyAuthentication,
+	middleware.ProvideAuthenticatorChain,
 )
 
 // Wiring for HTTP routing.
 var routing = wire.NewSet(
-	wire.Struct(new(router.DomainHandlers), "FooBarBazHandler", "UserHandler"),
+	wire.Struct(new(router.DomainHandlers), "AdminAPIKeyHandler", "AdminOAuthClientHandler", "AdminUserHandler", "FooBarBazHandler", "JWKSHandler", "OAuthHandler", "UserHandler"),
+	handlers.ProvideAdminAPIKeyHandler,
+	handlers.ProvideAdminOAuthClientHandler,
+	handlers.ProvideAdminUserHandler,
 	handlers.ProvideFooBarBazHandler,
+	handlers.ProvideJWKSHandler,
+	handlers.ProvideOAuthHandler,
 	handlers.ProvideUserHandler,
 	router.ProvideRouter,
 )
@@ -75,7 +142,7 @@ var routing = wire.NewSet(
 // )
 
 // Wiring for everything.
-func InitializeService() *http.HTTP {
+func InitializeService() (*http.HTTP, error) {
 	wire.Build(
 		// configurations
 		configurations,
@@ -89,7 +156,7 @@ func InitializeService() *http.HTTP {
 		routing,
 		// selected transport layer
 		http.ProvideHTTP)
-	return &http.HTTP{}
+	return &http.HTTP{}, nil
 }
 
 // // Wiring the event needs.